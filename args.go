@@ -0,0 +1,436 @@
+package goql
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// reNumber matches an int or float literal.
+// e.g. 10 | -1.5 | 6.02e23
+var reNumber = regexp.MustCompile(`^-?(?:0|[1-9]\d*)(?:\.\d+)?(?:[eE][+-]?\d+)?`)
+
+// valueKind is an alias to an int that has distinct constant values defined for it allowing it
+// to act as if it were a classic enum.
+type valueKind int
+
+// Kinds of values that can be passed as arguments.
+const (
+	// valueLiteral is an int, float, string, boolean, null, or enum literal.
+	valueLiteral valueKind = iota
+
+	// valueVariable is a reference to a variable of the operation.
+	valueVariable
+
+	// valueList is a list of values.
+	valueList
+
+	// valueObject is an object of named values.
+	valueObject
+)
+
+// value is an input value that is passed as an argument to a field, e.g. $id, 10, or
+// {active: true}.
+type value struct {
+	Kind valueKind
+
+	// Literal is the verbatim text of a literal value.
+	Literal string
+
+	// Variable is the variable a variable value refers to.
+	Variable token
+
+	// Items holds onto the values of a list value.
+	Items []value
+
+	// Fields holds onto the values of an object value.
+	Fields []argument
+}
+
+// tokens recurses through a value to gather all of the variables it refers to.
+func (v *value) tokens() []token {
+	switch v.Kind {
+	case valueVariable:
+		return []token{v.Variable}
+	case valueList:
+		var tokens []token
+		for i := range v.Items {
+			tokens = append(tokens, v.Items[i].tokens()...)
+		}
+		return tokens
+	case valueObject:
+		return arguments(v.Fields).tokens()
+	default:
+		return nil
+	}
+}
+
+// tokenize writes the GraphQL representation of a value to any type that implements the
+// io.Writer interface.
+func (v *value) tokenize(w io.Writer) {
+	switch v.Kind {
+	case valueVariable:
+		fmt.Fprintf(w, "$%s", v.Variable.Arg) //nolint:errcheck
+	case valueList:
+		io.WriteString(w, "[") //nolint:errcheck
+		for i := range v.Items {
+			if i > 0 {
+				io.WriteString(w, ", ") //nolint:errcheck
+			}
+			v.Items[i].tokenize(w)
+		}
+		io.WriteString(w, "]") //nolint:errcheck
+	case valueObject:
+		io.WriteString(w, "{") //nolint:errcheck
+		arguments(v.Fields).tokenize(w)
+		io.WriteString(w, "}") //nolint:errcheck
+	default:
+		io.WriteString(w, v.Literal) //nolint:errcheck
+	}
+}
+
+// renamed returns a deep copy of the value with the variables it refers to renamed according to
+// names, which maps the old names to the new ones.
+func (v *value) renamed(names map[string]string) value {
+	nv := *v
+	switch v.Kind {
+	case valueVariable:
+		if name, exists := names[v.Variable.Arg]; exists {
+			nv.Variable.Arg = name
+		}
+	case valueList:
+		nv.Items = make([]value, len(v.Items))
+		for i := range v.Items {
+			nv.Items[i] = v.Items[i].renamed(names)
+		}
+	case valueObject:
+		nv.Fields = arguments(v.Fields).renamed(names)
+	case valueLiteral:
+	}
+
+	return nv
+}
+
+// argument is a named value, either an argument of a field or a field of an object value.
+type argument struct {
+	Name  string
+	Value value
+}
+
+// arguments is a list of arguments.
+type arguments []argument
+
+// tokens gathers all of the variables that the arguments refer to.
+func (args arguments) tokens() []token {
+	var tokens []token
+	for i := range args {
+		tokens = append(tokens, args[i].Value.tokens()...)
+	}
+	return tokens
+}
+
+// renamed returns a deep copy of the arguments with the variables they refer to renamed
+// according to names, which maps the old names to the new ones.
+func (args arguments) renamed(names map[string]string) arguments {
+	if args == nil {
+		return nil
+	}
+
+	renamed := make(arguments, len(args))
+	for i := range args {
+		renamed[i] = argument{Name: args[i].Name, Value: args[i].Value.renamed(names)}
+	}
+
+	return renamed
+}
+
+// tokenize writes the GraphQL representation of the arguments, separated by commas, to any type
+// that implements the io.Writer interface.
+func (args arguments) tokenize(w io.Writer) {
+	for i := range args {
+		if i > 0 {
+			io.WriteString(w, ", ") //nolint:errcheck
+		}
+		fmt.Fprintf(w, "%s: ", args[i].Name) //nolint:errcheck
+		args[i].Value.tokenize(w)
+	}
+}
+
+// scanner reads through a single item of a goql struct tag.
+type scanner struct {
+	src string
+	pos int
+}
+
+// eof denotes whether or not the scanner has read through its entire source.
+func (s *scanner) eof() bool {
+	return s.pos >= len(s.src)
+}
+
+// peek returns the next byte of the source without consuming it, or 0 at the end of it.
+func (s *scanner) peek() byte {
+	if s.eof() {
+		return 0
+	}
+	return s.src[s.pos]
+}
+
+// skipSpace consumes any whitespace.
+func (s *scanner) skipSpace() {
+	for !s.eof() && unicode.IsSpace(rune(s.src[s.pos])) {
+		s.pos++
+	}
+}
+
+// skipSeparators consumes any whitespace and commas, which are insignificant between arguments
+// and values in GraphQL.
+func (s *scanner) skipSeparators() {
+	for !s.eof() && (unicode.IsSpace(rune(s.src[s.pos])) || s.src[s.pos] == ',') {
+		s.pos++
+	}
+}
+
+// expect consumes c, surrounded by optional whitespace, or returns an error if it is not next.
+func (s *scanner) expect(c byte) error {
+	s.skipSpace()
+	if s.peek() != c {
+		return s.errorf("expected %q", c)
+	}
+	s.pos++
+	s.skipSpace()
+	return nil
+}
+
+// name consumes a name, e.g. of a field, argument, or variable.
+func (s *scanner) name() (string, error) {
+	start := s.pos
+	for !s.eof() && (s.src[s.pos] == '_' || unicode.IsLetter(rune(s.src[s.pos])) || unicode.IsDigit(rune(s.src[s.pos]))) {
+		s.pos++
+	}
+
+	if start == s.pos {
+		return "", s.errorf("expected name")
+	}
+	return s.src[start:s.pos], nil
+}
+
+// errorf returns an error that points at the current position of the scanner.
+func (s *scanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d of %q", fmt.Sprintf(format, args...), s.pos, s.src)
+}
+
+// arguments consumes a parenthesized list of arguments.
+func (s *scanner) arguments() (arguments, error) {
+	if err := s.expect('('); err != nil {
+		return nil, err
+	}
+
+	var args arguments
+	for s.skipSeparators(); s.peek() != ')'; s.skipSeparators() {
+		if s.eof() {
+			return nil, s.errorf("expected \")\"")
+		}
+
+		arg, err := s.argument()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	s.pos++
+
+	return args, nil
+}
+
+// argument consumes a name and a value separated by a colon.
+func (s *scanner) argument() (argument, error) {
+	name, err := s.name()
+	if err != nil {
+		return argument{}, err
+	}
+
+	if err := s.expect(':'); err != nil {
+		return argument{}, err
+	}
+
+	v, err := s.value()
+	if err != nil {
+		return argument{}, err
+	}
+
+	return argument{Name: name, Value: v}, nil
+}
+
+// value consumes a value, which is either a variable or an int, float, string, boolean, null,
+// enum, list, or object literal. Variables are required to be typed, e.g. $id<ID!>, and can
+// optionally be given a default value, e.g. $size<Int>=25.
+func (s *scanner) value() (value, error) { //nolint:gocyclo
+	c := s.peek()
+	switch {
+	case c == '$':
+		s.pos++
+		name, err := s.name()
+		if err != nil {
+			return value{}, err
+		}
+
+		if s.peek() != '<' {
+			return value{}, s.errorf("expected type of variable $%s", name)
+		}
+
+		end := strings.IndexByte(s.src[s.pos:], '>')
+		if end == -1 {
+			return value{}, s.errorf("expected \">\"")
+		}
+
+		kind, err := parseType(s.src[s.pos+1 : s.pos+end])
+		if err != nil {
+			return value{}, fmt.Errorf("invalid type %q of variable $%s: %w", s.src[s.pos+1:s.pos+end], name, err)
+		}
+		s.pos += end + 1
+
+		variable := token{Kind: kind, Arg: name}
+
+		// Variables can be given a default value, e.g. $size<Int>=25.
+		if s.skipSpace(); s.peek() == '=' {
+			s.pos++
+			s.skipSpace()
+
+			def, err := s.value()
+			if err != nil {
+				return value{}, err
+			}
+
+			if len(def.tokens()) > 0 {
+				return value{}, s.errorf("default value of variable $%s cannot contain variables", name)
+			}
+
+			var sb strings.Builder
+			def.tokenize(&sb)
+			variable.Default = sb.String()
+		}
+
+		return value{Kind: valueVariable, Variable: variable}, nil
+	case c == '"':
+		start := s.pos
+		for s.pos++; !s.eof() && s.src[s.pos] != '"'; s.pos++ {
+			if s.src[s.pos] == '\\' {
+				s.pos++
+			}
+		}
+
+		if s.eof() {
+			return value{}, s.errorf("unterminated string")
+		}
+		s.pos++
+
+		return value{Kind: valueLiteral, Literal: s.src[start:s.pos]}, nil
+	case c == '[':
+		s.pos++
+
+		var items []value
+		for s.skipSeparators(); s.peek() != ']'; s.skipSeparators() {
+			if s.eof() {
+				return value{}, s.errorf("expected \"]\"")
+			}
+
+			item, err := s.value()
+			if err != nil {
+				return value{}, err
+			}
+			items = append(items, item)
+		}
+		s.pos++
+
+		return value{Kind: valueList, Items: items}, nil
+	case c == '{':
+		s.pos++
+
+		var fields []argument
+		for s.skipSeparators(); s.peek() != '}'; s.skipSeparators() {
+			if s.eof() {
+				return value{}, s.errorf("expected \"}\"")
+			}
+
+			field, err := s.argument()
+			if err != nil {
+				return value{}, err
+			}
+			fields = append(fields, field)
+		}
+		s.pos++
+
+		return value{Kind: valueObject, Fields: fields}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		number := reNumber.FindString(s.src[s.pos:])
+		if number == "" {
+			return value{}, s.errorf("invalid number")
+		}
+		s.pos += len(number)
+
+		return value{Kind: valueLiteral, Literal: number}, nil
+	default:
+		// Booleans, null, and enum values are all names as far as the syntax is concerned.
+		name, err := s.name()
+		if err != nil {
+			return value{}, s.errorf("expected value")
+		}
+
+		return value{Kind: valueLiteral, Literal: name}, nil
+	}
+}
+
+// parseType parses the type of a variable, which is a named type wrapped in any number of list
+// and non-null modifiers, e.g. [[Float!]!]. The type is returned without any insignificant
+// whitespace.
+func parseType(src string) (string, error) {
+	var sb strings.Builder
+
+	s := scanner{src: src}
+	if err := s.typeRef(&sb); err != nil {
+		return "", err
+	}
+
+	if s.skipSpace(); !s.eof() {
+		return "", s.errorf("unexpected %q", s.src[s.pos:])
+	}
+
+	return sb.String(), nil
+}
+
+// typeRef consumes a type, writing it to sb as it goes.
+func (s *scanner) typeRef(sb *strings.Builder) error {
+	s.skipSpace()
+
+	if s.peek() == '[' {
+		s.pos++
+		sb.WriteByte('[')
+
+		if err := s.typeRef(sb); err != nil {
+			return err
+		}
+
+		if s.skipSpace(); s.peek() != ']' {
+			return s.errorf("expected \"]\" to close list type")
+		}
+		s.pos++
+		sb.WriteByte(']')
+	} else {
+		start := s.pos
+		name, err := s.name()
+		if err != nil || unicode.IsDigit(rune(name[0])) {
+			s.pos = start
+			return s.errorf("expected type name")
+		}
+		sb.WriteString(name)
+	}
+
+	if s.skipSpace(); s.peek() == '!' {
+		s.pos++
+		sb.WriteByte('!')
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package goql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseType tests the parseType function.
+func TestParseType(t *testing.T) {
+	tt := []struct {
+		Name           string
+		Input          string
+		ExpectedOutput string // If ExpectedOutput == "", it implies an error.
+	}{
+		{
+			Name:           "Named",
+			Input:          "Int",
+			ExpectedOutput: "Int",
+		},
+		{
+			Name:           "NonNull",
+			Input:          "ID!",
+			ExpectedOutput: "ID!",
+		},
+		{
+			Name:           "List",
+			Input:          "[String!]!",
+			ExpectedOutput: "[String!]!",
+		},
+		{
+			Name:           "NestedList",
+			Input:          "[[Float!]!]",
+			ExpectedOutput: "[[Float!]!]",
+		},
+		{
+			Name:           "DeeplyNestedList",
+			Input:          "[[[Int]!]]!",
+			ExpectedOutput: "[[[Int]!]]!",
+		},
+		{
+			Name:           "Whitespace",
+			Input:          " [ [Float ! ] ! ] ",
+			ExpectedOutput: "[[Float!]!]",
+		},
+		{
+			Name:           "ErrorEmpty",
+			Input:          "",
+			ExpectedOutput: "",
+		},
+		{
+			Name:           "ErrorUnclosedList",
+			Input:          "[[Float!]",
+			ExpectedOutput: "",
+		},
+		{
+			Name:           "ErrorUnopenedList",
+			Input:          "Float!]",
+			ExpectedOutput: "",
+		},
+		{
+			Name:           "ErrorEmptyList",
+			Input:          "[]",
+			ExpectedOutput: "",
+		},
+		{
+			Name:           "ErrorDoubleNonNull",
+			Input:          "Float!!",
+			ExpectedOutput: "",
+		},
+		{
+			Name:           "ErrorInvalidName",
+			Input:          "[1Float]",
+			ExpectedOutput: "",
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			actualOutput, err := parseType(test.Input)
+			if err != nil {
+				if test.ExpectedOutput == "" {
+					// The error was expected, return without reporting anything.
+					return
+				}
+
+				t.Fatalf("error parsing type: %v", err)
+			}
+
+			if test.ExpectedOutput == "" {
+				t.Fatalf("expected error, got %s", actualOutput)
+			}
+
+			if e, a := test.ExpectedOutput, actualOutput; e != a {
+				t.Errorf("expected output to be %s, got %s", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
+
+// TestParseTagInvalidType tests that invalid variable types are reported along with the tag
+// and the variable they were found in.
+func TestParseTagInvalidType(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseTag(reflect.StructTag(`goql:"route(points:$points<[[Float!]>)"`))
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	for _, expected := range []string{`route(points:$points<[[Float!]>)`, `invalid type "[[Float!]" of variable $points`, `"]"`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %s, got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package goql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// defaultBatchWindow is the default of BatchOptions.Window.
+const defaultBatchWindow = 10 * time.Millisecond
+
+// BatchOptions configures how the Client batches operations that are performed concurrently
+// into a single request, which GraphQL servers such as Apollo Server and gqlgen (through its
+// transports) accept as a JSON array of operations and respond to with a JSON array of
+// responses. The zero value of BatchOptions disables batching.
+//
+// Operations are only batched with other operations that are sent with the same headers,
+// other than the headers that trace context is propagated through. Queries sent as GET
+// requests, see ClientOptions.UseGETForQueries, are never batched. Middleware, the ErrorMapper,
+// and AllowPartialData all apply to each of the operations of a batch individually. A batch is
+// only canceled once the contexts of all of its operations are done.
+type BatchOptions struct {
+	// MaxSize is the maximum number of operations sent in a single batch, a batch is sent as
+	// soon as it is full. Values of 1 or less disable batching.
+	MaxSize int
+
+	// Window is how long to wait for more operations to add to a batch after the first one
+	// was added to it. Defaults to 10ms.
+	Window time.Duration
+}
+
+// batcher collects the operations performed through a client into batches.
+type batcher struct {
+	client  *Client
+	options BatchOptions
+
+	// ignoredHeaders holds onto the canonical names of the headers that aren't taken into
+	// account when deciding which operations can be batched together.
+	ignoredHeaders map[string]bool
+
+	mu      sync.Mutex
+	pending map[string]*batch
+}
+
+// newBatcher returns a batcher for c, or nil if options disable batching.
+func newBatcher(c *Client, options BatchOptions) *batcher {
+	if options.MaxSize <= 1 {
+		return nil
+	}
+
+	if options.Window <= 0 {
+		options.Window = defaultBatchWindow
+	}
+
+	ignoredHeaders := make(map[string]bool)
+	for _, field := range c.telemetry.propagator.Fields() {
+		ignoredHeaders[http.CanonicalHeaderKey(field)] = true
+	}
+
+	return &batcher{
+		client:         c,
+		options:        options,
+		ignoredHeaders: ignoredHeaders,
+		pending:        make(map[string]*batch),
+	}
+}
+
+// batch is a batch of operations that are sent together.
+type batch struct {
+	key        string
+	ctx        context.Context
+	cancel     context.CancelFunc
+	header     http.Header
+	idempotent bool
+	calls      []*call
+	timer      *time.Timer
+
+	// waiting is the number of calls that are still waiting for their response, the batch is
+	// canceled once there are none left.
+	waiting int
+}
+
+// call is a single operation of a batch that is waiting for its response.
+type call struct {
+	body  []byte
+	attrs metric.MeasurementOption
+	done  chan struct{}
+
+	resp *Response
+	err  error
+}
+
+// do adds the operation whose request body is body to a batch of operations sent with the same
+// headers and waits for its response, whose size is recorded with attrs. The batch is retried as
+// a whole, if all of its operations are idempotent.
+func (b *batcher) do(ctx context.Context, body []byte, header http.Header, idempotent bool,
+	attrs metric.MeasurementOption) (*Response, error) {
+	op := call{
+		body:  body,
+		attrs: attrs,
+		done:  make(chan struct{}),
+	}
+
+	key := b.key(header)
+
+	b.mu.Lock()
+	bt, exists := b.pending[key]
+	if !exists {
+		// The batch outlives the operation that started it, so it must not be canceled along
+		// with it, but it still carries its values, e.g. its span. It's canceled once none of
+		// its operations are waiting for their response anymore instead.
+		bt = &batch{
+			key:        key,
+			header:     header.Clone(),
+			idempotent: true,
+		}
+		bt.ctx, bt.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		b.pending[key] = bt
+
+		bt.timer = time.AfterFunc(b.options.Window, func() {
+			b.flush(key, bt)
+		})
+	}
+
+	bt.idempotent = bt.idempotent && idempotent
+	bt.calls = append(bt.calls, &op)
+	bt.waiting++
+
+	// Full batches are sent right away, operations that come after them start a new batch.
+	full := len(bt.calls) >= b.options.MaxSize
+	if full {
+		bt.timer.Stop()
+		delete(b.pending, key)
+	}
+	b.mu.Unlock()
+
+	if full {
+		go b.send(bt)
+	}
+
+	select {
+	case <-op.done:
+		return op.resp, op.err
+	case <-ctx.Done():
+		b.abandon(bt)
+		return nil, ctx.Err()
+	}
+}
+
+// abandon is called when a call of bt stops waiting for its response before receiving it. Once
+// none of them are waiting anymore, bt is canceled, or dropped if it wasn't sent yet.
+func (b *batcher) abandon(bt *batch) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bt.waiting--
+	if bt.waiting > 0 {
+		return
+	}
+
+	if b.pending[bt.key] == bt {
+		bt.timer.Stop()
+		delete(b.pending, bt.key)
+	}
+	bt.cancel()
+}
+
+// key returns the key of the batches that operations sent with header are added to.
+func (b *batcher) key(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		if !b.ignoredHeaders[http.CanonicalHeaderKey(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s: %q\n", http.CanonicalHeaderKey(name), header[name]) //nolint:errcheck
+	}
+	return sb.String()
+}
+
+// flush sends bt once its window elapsed, unless it was already sent because it filled up.
+func (b *batcher) flush(key string, bt *batch) {
+	b.mu.Lock()
+	if b.pending[key] != bt {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.pending, key)
+	b.mu.Unlock()
+
+	b.send(bt)
+}
+
+// send sends bt and hands the response to each of its operations to the call waiting for it.
+func (b *batcher) send(bt *batch) {
+	defer bt.cancel()
+
+	c := b.client
+
+	// A batch of one is sent as a single operation.
+	if len(bt.calls) == 1 {
+		op := bt.calls[0]
+		op.resp, op.err = c.withRetries(bt.ctx, bt.idempotent, func() (*Response, error) {
+			resp, err := c.send(bt.ctx, http.MethodPost, c.url, bytes.NewReader(op.body), bt.header, false)
+			if resp != nil {
+				c.telemetry.responseSize.Record(bt.ctx, resp.size, op.attrs)
+			}
+			return resp, err
+		})
+		close(op.done)
+		return
+	}
+
+	var body bytes.Buffer
+	body.WriteByte('[')
+	for i := range bt.calls {
+		if i > 0 {
+			body.WriteByte(',')
+		}
+		body.Write(bt.calls[i].body)
+	}
+	body.WriteByte(']')
+
+	resp, err := c.withRetries(bt.ctx, bt.idempotent, func() (*Response, error) {
+		return c.send(bt.ctx, http.MethodPost, c.url, bytes.NewReader(body.Bytes()), bt.header, true)
+	})
+
+	if err == nil && len(resp.batch) != len(bt.calls) {
+		err = fmt.Errorf("received %d responses to a batch of %d operations", len(resp.batch), len(bt.calls))
+	}
+
+	for i, op := range bt.calls {
+		if err != nil {
+			op.err = err
+		} else {
+			op.resp = &resp.batch[i]
+			c.telemetry.responseSize.Record(bt.ctx, op.resp.size, op.attrs)
+		}
+		close(op.done)
+	}
+}
@@ -0,0 +1,274 @@
+package goql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// TestBatching tests that operations performed concurrently are sent in batches and that the
+// responses to them are handed back to each caller.
+func TestBatching(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		Name             string
+		Options          BatchOptions
+		Operations       int
+		ExpectedRequests int32
+	}{
+		{
+			Name:             "Full",
+			Options:          BatchOptions{MaxSize: 4, Window: time.Minute},
+			Operations:       8,
+			ExpectedRequests: 2,
+		},
+		{
+			Name:             "WindowElapsed",
+			Options:          BatchOptions{MaxSize: 10, Window: 50 * time.Millisecond},
+			Operations:       3,
+			ExpectedRequests: 1,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			var requests int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+
+				var batch []request
+				if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+					t.Errorf("error decoding batch: %v", err)
+				}
+
+				// Respond with the id of each operation, or an error for odd ids.
+				resps := make([]map[string]interface{}, len(batch))
+				for i := range batch {
+					id := batch[i].Variables["id"].(float64)
+					if int(id)%2 == 1 {
+						resps[i] = map[string]interface{}{"data": nil, "errors": []map[string]interface{}{{"message": "odd"}}}
+					} else {
+						resps[i] = map[string]interface{}{"data": map[string]interface{}{"user": map[string]interface{}{"id": id}}}
+					}
+				}
+
+				w.Header().Set("Content-Type", applicationJSON)
+				json.NewEncoder(w).Encode(resps) //nolint:errcheck
+			}))
+			t.Cleanup(ts.Close)
+
+			reader := sdkmetric.NewManualReader()
+
+			var mapped int32
+			client := NewClient(ts.URL, ClientOptions{
+				Batching:      test.Options,
+				MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+				ErrorMapper: func(_ int, errs Errors) error {
+					atomic.AddInt32(&mapped, 1)
+					return errs
+				},
+			})
+
+			var wg sync.WaitGroup
+			for i := 0; i < test.Operations; i++ {
+				wg.Add(1)
+				go func(id int) {
+					defer wg.Done()
+
+					var resp struct {
+						User struct {
+							ID int `json:"id"`
+						} `json:"user"`
+					}
+
+					err := client.CustomOperation(context.Background(), "query ($id: Int!) { user(id: $id) { id } }",
+						map[string]interface{}{"id": id}, &resp)
+
+					if id%2 == 1 {
+						var errs Errors
+						if !errors.As(err, &errs) || errs[0].Message != "odd" {
+							t.Errorf("expected error of operation %d to be odd, got %v", id, err)
+						}
+						return
+					}
+
+					if err != nil {
+						t.Errorf("error performing operation %d: %v", id, err)
+					}
+
+					if e, a := id, resp.User.ID; e != a {
+						t.Errorf("expected id to be %d, got %d", e, a)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			if e, a := test.ExpectedRequests, atomic.LoadInt32(&requests); e != a {
+				t.Errorf("expected %d requests, got %d", e, a)
+			}
+
+			if e, a := int32(test.Operations/2), atomic.LoadInt32(&mapped); e != a {
+				t.Errorf("expected error mapper to be called %d times, got %d", e, a)
+			}
+
+			var rm metricdata.ResourceMetrics
+			if err := reader.Collect(context.Background(), &rm); err != nil {
+				t.Fatalf("error collecting metrics: %v", err)
+			}
+
+			// The size of the response is recorded for each of the operations of a batch.
+			var sizes uint64
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					if h, ok := m.Data.(metricdata.Histogram[int64]); ok && m.Name == "goql.client.response.size" {
+						for _, dp := range h.DataPoints {
+							sizes += dp.Count
+						}
+					}
+				}
+			}
+
+			if e, a := uint64(test.Operations), sizes; e != a {
+				t.Errorf("expected %d response sizes to be recorded, got %d", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
+
+// TestBatchAbandoned tests that batches are canceled once none of their operations are waiting
+// for their response anymore.
+func TestBatchAbandoned(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		Name             string
+		Options          BatchOptions
+		ExpectedRequests int32
+	}{
+		{
+			Name:             "Sent",
+			Options:          BatchOptions{MaxSize: 2, Window: time.Minute},
+			ExpectedRequests: 1,
+		},
+		{
+			Name:             "Pending",
+			Options:          BatchOptions{MaxSize: 10, Window: time.Minute},
+			ExpectedRequests: 0,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			var requests int32
+			canceled := make(chan struct{})
+			ts := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+
+				// Hang until the client gives up on the request, which the server only notices
+				// once the body has been read.
+				io.Copy(io.Discard, r.Body) //nolint:errcheck
+				select {
+				case <-r.Context().Done():
+					close(canceled)
+				case <-time.After(5 * time.Second):
+				}
+			}))
+			t.Cleanup(ts.Close)
+
+			client := NewClient(ts.URL, ClientOptions{Batching: test.Options})
+
+			var wg sync.WaitGroup
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+					defer cancel()
+
+					err := client.CustomOperation(ctx, "query { viewer { id } }", nil, nil)
+					if !errors.Is(err, context.DeadlineExceeded) {
+						t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if test.ExpectedRequests > 0 {
+				select {
+				case <-canceled:
+				case <-time.After(5 * time.Second):
+					t.Fatal("expected request of abandoned batch to be canceled")
+				}
+			}
+
+			if e, a := test.ExpectedRequests, atomic.LoadInt32(&requests); e != a {
+				t.Errorf("expected %d requests, got %d", e, a)
+			}
+
+			client.batcher.mu.Lock()
+			defer client.batcher.mu.Unlock()
+			if len(client.batcher.pending) != 0 {
+				t.Errorf("expected abandoned batch to be dropped, got %d pending batches", len(client.batcher.pending))
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
+
+// TestBatchKey tests that operations are only batched with operations sent with the same headers.
+func TestBatchKey(t *testing.T) {
+	t.Parallel()
+
+	b := batcher{ignoredHeaders: map[string]bool{"Traceparent": true}}
+
+	authorized := http.Header{"Authorization": []string{"Bearer a"}, "Traceparent": []string{"00-a"}}
+	tt := []struct {
+		Name           string
+		Input          http.Header
+		ExpectedOutput bool
+	}{
+		{
+			Name:           "SameHeaders",
+			Input:          http.Header{"Authorization": []string{"Bearer a"}, "Traceparent": []string{"00-a"}},
+			ExpectedOutput: true,
+		},
+		{
+			Name:           "DifferentTraceContext",
+			Input:          http.Header{"Authorization": []string{"Bearer a"}, "Traceparent": []string{"00-b"}},
+			ExpectedOutput: true,
+		},
+		{
+			Name:           "DifferentHeaders",
+			Input:          http.Header{"Authorization": []string{"Bearer b"}, "Traceparent": []string{"00-a"}},
+			ExpectedOutput: false,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			if e, a := test.ExpectedOutput, b.key(authorized) == b.key(test.Input); e != a {
+				t.Errorf("expected operations to be batched together to be %t, got %t", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
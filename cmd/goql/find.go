@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// goqlPath is the import path of goql.
+const goqlPath = "github.com/getoutreach/goql"
+
+// methodOperationTypes maps the methods of goql.Client that perform mutations and subscriptions
+// to the type of operation they perform, the others perform queries.
+var methodOperationTypes = map[string]string{
+	"Mutate":               "mutation",
+	"MutateWithHeaders":    "mutation",
+	"MutateAll":            "mutation",
+	"MutateAllWithHeaders": "mutation",
+	"Subscribe":            "subscription",
+	"SubscribeWithHeaders": "subscription",
+}
+
+// operation is a type that's used as the OperationType of a goql.Operation.
+type operation struct {
+	// typeName is the name of the type, which is declared at the top level of its package.
+	typeName string
+
+	// operationType is the type of operation the type is performed as, i.e. "query",
+	// "mutation", or "subscription".
+	operationType string
+}
+
+// finder finds the operations of a type-checked package.
+type finder struct {
+	fset *token.FileSet
+	pkg  *types.Package
+	info *types.Info
+
+	// literals and variables map the goql.Operation literals passed to the methods of
+	// goql.Client, and the variables holding onto them, to the type of operation performed.
+	literals  map[*ast.CompositeLit]string
+	variables map[types.Object]string
+
+	// warnings holds onto the uses of goql.Operation whose type cannot be rendered.
+	warnings []string
+}
+
+// findOperations returns the types of the package pkg that are used as the OperationType of a
+// goql.Operation, sorted by name, along with warnings about the ones that cannot be rendered.
+// Operations are assumed to be queries, unless they're passed to the methods of goql.Client
+// that perform mutations or subscriptions, either directly or through a variable.
+func findOperations(fset *token.FileSet, pkg *types.Package, info *types.Info, files []*ast.File) ([]operation, []string) {
+	f := finder{
+		fset:      fset,
+		pkg:       pkg,
+		info:      info,
+		literals:  make(map[*ast.CompositeLit]string),
+		variables: make(map[types.Object]string),
+	}
+
+	for _, file := range files {
+		ast.Inspect(file, f.call)
+	}
+
+	found := make(map[string]operation)
+	for _, file := range files {
+		var stack []ast.Node
+		ast.Inspect(file, func(n ast.Node) bool {
+			if n == nil {
+				stack = stack[:len(stack)-1]
+				return true
+			}
+
+			if lit, ok := n.(*ast.CompositeLit); ok && f.isOperation(info.TypeOf(lit)) {
+				if op, ok := f.operation(lit, stack); ok {
+					if existing, exists := found[op.typeName]; exists && existing.operationType != op.operationType {
+						f.warnf(lit, "%s is performed as both a %s and a %s, it's rendered as a %s",
+							op.typeName, existing.operationType, op.operationType, existing.operationType)
+					} else if !exists {
+						found[op.typeName] = op
+					}
+				}
+			}
+
+			stack = append(stack, n)
+			return true
+		})
+	}
+
+	operations := make([]operation, 0, len(found))
+	for _, op := range found {
+		operations = append(operations, op)
+	}
+	sort.Slice(operations, func(i, j int) bool { return operations[i].typeName < operations[j].typeName })
+
+	return operations, f.warnings
+}
+
+// warnf adds a warning about the given node.
+func (f *finder) warnf(n ast.Node, format string, args ...interface{}) {
+	f.warnings = append(f.warnings, fmt.Sprintf("%s: %s", f.fset.Position(n.Pos()), fmt.Sprintf(format, args...)))
+}
+
+// isOperation denotes whether or not t is goql.Operation.
+func (f *finder) isOperation(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == goqlPath && obj.Name() == "Operation"
+}
+
+// call records the type of operation performed by calls to the methods of goql.Client, which
+// is visited by ast.Inspect.
+func (f *finder) call(n ast.Node) bool {
+	call, ok := n.(*ast.CallExpr)
+	if !ok {
+		return true
+	}
+
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return true
+	}
+
+	method, ok := f.info.Uses[sel.Sel].(*types.Func)
+	if !ok || method.Pkg() == nil || method.Pkg().Path() != goqlPath {
+		return true
+	}
+
+	operationType, ok := methodOperationTypes[method.Name()]
+	if !ok {
+		return true
+	}
+
+	for _, arg := range call.Args {
+		f.record(arg, operationType)
+	}
+	return true
+}
+
+// record records that the operations expr evaluates to are performed as the given type of
+// operation.
+func (f *finder) record(expr ast.Expr, operationType string) {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			f.record(e.X, operationType)
+		}
+	case *ast.CompositeLit:
+		if f.isOperation(f.info.TypeOf(e)) {
+			f.literals[e] = operationType
+			return
+		}
+
+		// Slices of operations passed to MutateAllWithHeaders.
+		for _, elt := range e.Elts {
+			f.record(elt, operationType)
+		}
+	case *ast.Ident:
+		if obj := f.info.Uses[e]; obj != nil {
+			f.variables[obj] = operationType
+		}
+	}
+}
+
+// operation returns the operation of the goql.Operation literal lit, whose ancestors are held
+// onto by stack.
+func (f *finder) operation(lit *ast.CompositeLit, stack []ast.Node) (operation, bool) {
+	var expr ast.Expr
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "OperationType" {
+				expr = kv.Value
+			}
+		}
+	}
+
+	if expr == nil {
+		return operation{}, false
+	}
+
+	t := f.info.TypeOf(expr)
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	named, ok := t.(*types.Named)
+	if !ok {
+		f.warnf(expr, "cannot render operation of type %s, it isn't a named type", t)
+		return operation{}, false
+	}
+
+	obj := named.Obj()
+	switch {
+	case obj.Pkg() != f.pkg:
+		f.warnf(expr, "cannot render operation of type %s, it's declared in another package", t)
+		return operation{}, false
+	case obj.Parent() != f.pkg.Scope():
+		f.warnf(expr, "cannot render operation of type %s, it's declared within a function", obj.Name())
+		return operation{}, false
+	case named.TypeParams().Len() > 0:
+		f.warnf(expr, "cannot render operation of type %s, it's a generic type", obj.Name())
+		return operation{}, false
+	}
+
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		f.warnf(expr, "cannot render operation of type %s, it isn't a struct type", obj.Name())
+		return operation{}, false
+	}
+
+	return operation{typeName: obj.Name(), operationType: f.operationType(lit, stack)}, true
+}
+
+// operationType returns the type of operation that the goql.Operation literal lit, whose
+// ancestors are held onto by stack, is performed as.
+func (f *finder) operationType(lit *ast.CompositeLit, stack []ast.Node) string {
+	if operationType, ok := f.literals[lit]; ok {
+		return operationType
+	}
+
+	// The literal, or its address, may be assigned to a variable that's passed to goql.Client.
+	var value ast.Node = lit
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch parent := stack[i].(type) {
+		case *ast.UnaryExpr, *ast.ParenExpr:
+			value = parent
+			continue
+		case *ast.AssignStmt:
+			for j, rhs := range parent.Rhs {
+				if rhs == value && j < len(parent.Lhs) {
+					if operationType, ok := f.variable(parent.Lhs[j]); ok {
+						return operationType
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for j, rhs := range parent.Values {
+				if rhs == value && j < len(parent.Names) {
+					if operationType, ok := f.variable(parent.Names[j]); ok {
+						return operationType
+					}
+				}
+			}
+		}
+		break
+	}
+
+	return "query"
+}
+
+// variable returns the type of operation performed with the variable expr refers to, if it's
+// passed to goql.Client.
+func (f *finder) variable(expr ast.Expr) (string, bool) {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+
+	obj := f.info.Defs[ident]
+	if obj == nil {
+		obj = f.info.Uses[ident]
+	}
+
+	operationType, ok := f.variables[obj]
+	return operationType, ok
+}
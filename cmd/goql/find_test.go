@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+// goqlStub is the part of the API of goql that findOperations relies on.
+const goqlStub = `package goql
+
+type Operation struct {
+	OperationType interface{}
+	Variables     map[string]interface{}
+}
+
+type Client struct{}
+
+func (c *Client) Query(ctx interface{}, operation *Operation) error { return nil }
+func (c *Client) Mutate(ctx interface{}, operation *Operation) error { return nil }
+func (c *Client) MutateAllWithHeaders(ctx interface{}, operations []*Operation, headers interface{}) error { return nil }
+func (c *Client) Subscribe(ctx interface{}, operation *Operation, handler interface{}) error { return nil }
+`
+
+// importerFunc implements types.Importer with a func.
+type importerFunc func(path string) (*types.Package, error)
+
+// Import implements types.Importer.
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+// check type-checks the given source files of a package, which may only import goql.
+func check(t *testing.T, src ...string) (*token.FileSet, *types.Package, *types.Info, []*ast.File) {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	parse := func(name, src string) *ast.File {
+		file, err := parser.ParseFile(fset, name, src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+
+	imp := importerFunc(func(path string) (*types.Package, error) {
+		if path != goqlPath {
+			return nil, fmt.Errorf("unexpected import %s", path)
+		}
+		return new(types.Config).Check(goqlPath, fset, []*ast.File{parse("goql.go", goqlStub)}, nil)
+	})
+
+	files := make([]*ast.File, 0, len(src))
+	for i := range src {
+		files = append(files, parse(fmt.Sprintf("ops%d.go", i), src[i]))
+	}
+
+	info := types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+
+	pkg, err := (&types.Config{Importer: imp}).Check("example.com/ops", fset, files, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fset, pkg, &info, files
+}
+
+func TestFindOperations(t *testing.T) {
+	t.Parallel()
+
+	fset, pkg, info, files := check(t, `package ops
+
+import "github.com/getoutreach/goql"
+
+type GetUser struct{ User struct{ ID string } }
+type ListUsers struct{ Users []struct{ ID string } }
+type UpdateUser struct{ UpdateUser struct{ ID string } }
+type DeleteUser struct{ DeleteUser struct{ ID string } }
+type CreateUser struct{ CreateUser struct{ ID string } }
+type UserUpdated struct{ UserUpdated struct{ ID string } }
+type Users []string
+
+func do(c *goql.Client) {
+	var q GetUser
+	c.Query(nil, &goql.Operation{OperationType: &q})
+
+	_ = goql.Operation{OperationType: ListUsers{}}
+
+	c.Mutate(nil, &goql.Operation{OperationType: &UpdateUser{}})
+
+	op := &goql.Operation{OperationType: new(DeleteUser)}
+	c.Mutate(nil, op)
+
+	var create = goql.Operation{OperationType: &CreateUser{}}
+	c.MutateAllWithHeaders(nil, []*goql.Operation{&create}, nil)
+
+	c.Subscribe(nil, &goql.Operation{OperationType: &UserUpdated{}}, nil)
+
+	type local struct{ ID string }
+	_ = goql.Operation{OperationType: local{}}
+	_ = goql.Operation{OperationType: Users{}}
+	_ = goql.Operation{OperationType: struct{ ID string }{}}
+}
+`)
+
+	operations, warnings := findOperations(fset, pkg, info, files)
+
+	expected := []operation{
+		{typeName: "CreateUser", operationType: "mutation"},
+		{typeName: "DeleteUser", operationType: "mutation"},
+		{typeName: "GetUser", operationType: "query"},
+		{typeName: "ListUsers", operationType: "query"},
+		{typeName: "UpdateUser", operationType: "mutation"},
+		{typeName: "UserUpdated", operationType: "subscription"},
+	}
+
+	if !reflect.DeepEqual(operations, expected) {
+		t.Errorf("expected operations %+v, got %+v", expected, operations)
+	}
+
+	expectedWarnings := []string{
+		"ops0.go:30:36: cannot render operation of type local, it's declared within a function",
+		"ops0.go:31:36: cannot render operation of type Users, it isn't a struct type",
+		"ops0.go:32:36: cannot render operation of type struct{ID string}, it isn't a named type",
+	}
+
+	if !reflect.DeepEqual(warnings, expectedWarnings) {
+		t.Errorf("expected warnings %q, got %q", expectedWarnings, warnings)
+	}
+}
@@ -0,0 +1,45 @@
+// Command goql provides tooling around the operations of goql clients.
+//
+// Usage:
+//
+//	goql render [-out <dir>] [-json-tags] [<package>...]
+//
+// The render subcommand loads the given packages, which default to the one in the current
+// directory, and finds every type that's used as the OperationType of a goql.Operation. The
+// pretty-printed GraphQL document of each is written to <type>.graphql, either in the directory
+// of its package or in the one given with -out, e.g. to review the actual operations in pull
+// requests or to ingest them into a schema registry. Operations are rendered as queries unless
+// they're passed to the methods of goql.Client that perform mutations or subscriptions. Types
+// declared within functions or in test files aren't rendered.
+//
+// The documents are rendered by running a test that's added to each package through an overlay,
+// so the packages and their tests need to compile. The test is run with go test, which means the
+// init functions of the packages and their dependencies run, as does the TestMain function of
+// each package if it has one, so render packages whose tests have side effects with care.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "goql: %v\n", err) //nolint:errcheck
+		os.Exit(1)
+	}
+}
+
+// run runs goql with the given command-line arguments.
+func run(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing subcommand, expected render")
+	}
+
+	switch args[0] {
+	case "render":
+		return renderCommand(args[1:])
+	default:
+		return fmt.Errorf("unknown subcommand %q, expected render", args[0])
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// listedPackage is a package as listed by go list -json.
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	Name       string
+	GoFiles    []string
+	Export     string
+	ImportMap  map[string]string
+	DepOnly    bool
+	Error      *struct{ Err string }
+}
+
+// renderCommand runs the render subcommand with the given command-line arguments.
+func renderCommand(args []string) error { //nolint:funlen
+	fs := flag.NewFlagSet("goql render", flag.ContinueOnError)
+
+	out := fs.String("out", "", "directory to write the documents to, defaults to the directory of each package")
+	jsonTags := fs.Bool("json-tags", false, "fall back to json struct tags, as with goql.OptFallbackJSONTag")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	packages, exports, err := listPackages(patterns)
+	if err != nil {
+		return err
+	}
+
+	written := make(map[string]string)
+	for _, pkg := range packages {
+		operations, warnings, err := loadOperations(pkg, exports)
+		if err != nil {
+			return err
+		}
+
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "goql render: warning: %s\n", warning) //nolint:errcheck
+		}
+
+		if len(operations) == 0 {
+			continue
+		}
+
+		dir := pkg.Dir
+		if *out != "" {
+			if dir, err = filepath.Abs(*out); err != nil {
+				return err
+			}
+
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+		}
+
+		documents := make([]renderedDocument, 0, len(operations))
+		for _, op := range operations {
+			path := filepath.Join(dir, op.typeName+".graphql")
+			if other, exists := written[path]; exists {
+				return fmt.Errorf("both %s.%s and %s would be written to %s", pkg.ImportPath, op.typeName, other, path)
+			}
+			written[path] = pkg.ImportPath + "." + op.typeName
+
+			documents = append(documents, renderedDocument{Path: path, operation: op})
+		}
+
+		if err := render(pkg, documents, *jsonTags); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listPackages lists the packages matched by patterns through go list, which also builds the
+// export data of all of their dependencies. It returns the packages along with the paths to
+// the export data of every package, keyed by import path.
+func listPackages(patterns []string) ([]listedPackage, map[string]string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("go", append([]string{"list", "-e", "-export", "-deps", "-json"}, patterns...)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, nil, fmt.Errorf("go list: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var packages []listedPackage
+	exports := make(map[string]string)
+
+	dec := json.NewDecoder(&stdout)
+	for {
+		var pkg listedPackage
+		if err := dec.Decode(&pkg); err == io.EOF { //nolint:errorlint // Why: Decode returns io.EOF as is.
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("decode go list output: %w", err)
+		}
+
+		if pkg.Export != "" {
+			exports[pkg.ImportPath] = pkg.Export
+		}
+
+		if pkg.DepOnly {
+			continue
+		}
+
+		if pkg.Error != nil {
+			return nil, nil, fmt.Errorf("%s: %s", pkg.ImportPath, pkg.Error.Err)
+		}
+		packages = append(packages, pkg)
+	}
+
+	return packages, exports, nil
+}
+
+// loadOperations parses and type-checks pkg, importing its dependencies from the given export
+// data, and finds its operations.
+func loadOperations(pkg listedPackage, exports map[string]string) ([]operation, []string, error) {
+	fset := token.NewFileSet()
+
+	files := make([]*ast.File, 0, len(pkg.GoFiles))
+	for _, name := range pkg.GoFiles {
+		file, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, name), nil, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, file)
+	}
+
+	lookup := func(path string) (io.ReadCloser, error) {
+		if mapped, ok := pkg.ImportMap[path]; ok {
+			path = mapped
+		}
+
+		export, ok := exports[path]
+		if !ok {
+			return nil, fmt.Errorf("no export data for %s", path)
+		}
+		return os.Open(export)
+	}
+
+	info := types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "gc", lookup)}
+	checked, err := conf.Check(pkg.ImportPath, fset, files, &info)
+	if err != nil {
+		return nil, nil, fmt.Errorf("type-check %s: %w", pkg.ImportPath, err)
+	}
+
+	operations, warnings := findOperations(fset, checked, &info, files)
+	return operations, warnings, nil
+}
+
+// renderedDocument is the document of an operation, along with the path it's written to.
+type renderedDocument struct {
+	Path string
+	operation
+}
+
+// Func returns the goql function that writes the document.
+func (d renderedDocument) Func() string {
+	return "Write" + strings.ToUpper(d.operationType[:1]) + d.operationType[1:] + "Document"
+}
+
+// Type returns the name of the type of the operation.
+func (d renderedDocument) Type() string {
+	return d.typeName
+}
+
+// renderTest is the test that renders the documents of the operations of a package. It's added
+// to the package through an overlay, since the operations may be unexported.
+var renderTest = template.Must(template.New("render").Parse(`// Code generated by goql render. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	goqlrender "github.com/getoutreach/goql"
+)
+
+func TestGoqlRenderDocuments(t *testing.T) {
+	documents := []struct {
+		path  string
+		write func(io.Writer) error
+	}{
+{{- range .Documents}}
+		{ {{printf "%q" .Path}}, func(w io.Writer) error { return goqlrender.{{.Func}}(w, {{.Type}}{}{{$.Options}}) } },
+{{- end}}
+	}
+
+	for _, d := range documents {
+		f, err := os.Create(d.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := d.write(f); err != nil {
+			f.Close()
+			t.Fatalf("%s: %v", d.path, err)
+		}
+
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+`))
+
+// render writes the documents of the operations of pkg, by running a test that's added to it.
+func render(pkg listedPackage, documents []renderedDocument, jsonTags bool) error {
+	if pkg.ImportPath == goqlPath {
+		return fmt.Errorf("cannot render the operations of %s itself", goqlPath)
+	}
+
+	tmp, err := os.MkdirTemp("", "goql-render")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	var options string
+	if jsonTags {
+		options = ", goqlrender.OptFallbackJSONTag"
+	}
+
+	var test bytes.Buffer
+	if err := renderTest.Execute(&test, map[string]interface{}{
+		"Package":   pkg.Name,
+		"Documents": documents,
+		"Options":   options,
+	}); err != nil {
+		return err
+	}
+
+	testPath := filepath.Join(tmp, "render_test.go")
+	if err := os.WriteFile(testPath, test.Bytes(), 0o600); err != nil {
+		return err
+	}
+
+	overlay, err := json.Marshal(map[string]map[string]string{
+		"Replace": {filepath.Join(pkg.Dir, "goql_render_documents_test.go"): testPath},
+	})
+	if err != nil {
+		return err
+	}
+
+	overlayPath := filepath.Join(tmp, "overlay.json")
+	if err := os.WriteFile(overlayPath, overlay, 0o600); err != nil {
+		return err
+	}
+
+	//nolint:gosec // Why: the arguments are the import path of a listed package and paths of our own.
+	cmd := exec.Command("go", "test", "-overlay", overlayPath, "-run", "^TestGoqlRenderDocuments$", "-count", "1", pkg.ImportPath)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("render the operations of %s: %w: %s", pkg.ImportPath, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestRenderCommand tests that the render subcommand writes the documents of the operations of
+// a package to the directory given with -out, which it creates.
+func TestRenderCommand(t *testing.T) {
+	t.Parallel()
+
+	if testing.Short() {
+		t.Skip("renders through go list and go test")
+	}
+
+	out := filepath.Join(t.TempDir(), "graphql", "operations")
+	if err := renderCommand([]string{"-out", out, "./testdata/render"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"getUser.graphql": `query ($id: ID!) {
+  user(id: $id) {
+    id
+    name
+  }
+}
+`,
+		"UpdateUser.graphql": `mutation ($id: ID!, $name: String!) {
+  updateUser(id: $id, name: $name) {
+    id
+  }
+}
+`,
+	}
+
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	documents := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		b, err := os.ReadFile(filepath.Join(out, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		documents[entry.Name()] = string(b)
+	}
+
+	if diff := cmp.Diff(expected, documents); diff != "" {
+		t.Errorf("unexpected documents (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,29 @@
+// Package render holds the operations that the tests of goql render renders.
+package render
+
+import (
+	"context"
+
+	"github.com/getoutreach/goql"
+)
+
+type getUser struct {
+	User struct {
+		ID   string `goql:"keep"`
+		Name string
+	} `goql:"user(id:$id<ID!>)"`
+}
+
+type UpdateUser struct {
+	UpdateUser struct {
+		ID string `goql:"keep"`
+	} `goql:"updateUser(id:$id<ID!>,name:$name<String!>)"`
+}
+
+// Do performs the operations.
+func Do(ctx context.Context, c *goql.Client) error {
+	if err := c.Query(ctx, &goql.Operation{OperationType: &getUser{}}); err != nil {
+		return err
+	}
+	return c.Mutate(ctx, &goql.Operation{OperationType: &UpdateUser{}})
+}
@@ -0,0 +1,762 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/getoutreach/goql/schema"
+)
+
+// document is an executable document read from a .graphql file.
+type document struct {
+	path string
+	*schema.QueryDocument
+}
+
+// goType is the Go type that a custom scalar is decoded into.
+type goType struct {
+	// name is the name of the type as it's referred to in the generated file, e.g. time.Time.
+	name string
+
+	// importPath is the import path of the package of the type, if it isn't builtin.
+	importPath string
+
+	// nilable denotes whether or not the type can hold null as is, without a pointer.
+	nilable bool
+}
+
+// parseGoType parses the Go type of a -scalar flag, which is either the name of a builtin type,
+// e.g. string, or the import path of a package and the name of a type within it, e.g. time.Time
+// or github.com/shopspring/decimal.Decimal.
+func parseGoType(s string) goType {
+	i := strings.LastIndex(s, ".")
+	if i == -1 {
+		return goType{name: s}
+	}
+
+	importPath := s[:i]
+	pkg := importPath[strings.LastIndex(importPath, "/")+1:]
+	return goType{name: pkg + "." + s[i+1:], importPath: importPath}
+}
+
+// rawMessage is the Go type of custom scalars that aren't mapped to a Go type.
+var rawMessage = goType{name: "json.RawMessage", importPath: "encoding/json", nilable: true}
+
+// builtinScalars maps the builtin scalar types of GraphQL to their Go types.
+var builtinScalars = map[string]goType{
+	"Int":     {name: "int"},
+	"Float":   {name: "float64"},
+	"String":  {name: "string"},
+	"Boolean": {name: "bool"},
+	"ID":      {name: "string"},
+}
+
+// generator generates the Go types of the operations of executable documents.
+type generator struct {
+	schema  *schema.Schema
+	scalars map[string]goType
+	imports map[string]bool
+
+	// fragments holds onto the named fragments of all of the documents.
+	fragments map[string]*schema.FragmentDefinition
+
+	// variables holds onto the variables that the selections being generated can refer to.
+	// Named fragments can be spread by any operation, so they can refer to the variables of all
+	// of the operations, as long as those that share a name share a type as well.
+	variables         map[string]*schema.VariableDefinition
+	fragmentVariables map[string]*schema.VariableDefinition
+
+	// declared maps the names of the Go types declared so far to what they were declared for.
+	declared map[string]string
+
+	// inputs and enums hold onto the input object and enum types that are used by the
+	// operations, inputQueue onto the input object types that are yet to be generated.
+	inputs     map[string]string
+	inputQueue []*schema.Type
+	enums      map[string]string
+
+	out bytes.Buffer
+}
+
+// generate generates the Go source of a file of the package pkg that declares the types of the
+// operations and named fragments of documents, as run against the schema s. scalars maps the
+// names of custom scalars to the Go types they're decoded into, see parseGoType.
+func generate(s *schema.Schema, pkg string, scalars map[string]string, documents []document) ([]byte, error) { //nolint:funlen
+	g := generator{
+		schema:            s,
+		scalars:           make(map[string]goType, len(scalars)),
+		imports:           make(map[string]bool),
+		fragments:         make(map[string]*schema.FragmentDefinition),
+		fragmentVariables: make(map[string]*schema.VariableDefinition),
+		declared:          make(map[string]string),
+		inputs:            make(map[string]string),
+		enums:             make(map[string]string),
+	}
+
+	for name, t := range scalars {
+		g.scalars[name] = parseGoType(t)
+	}
+
+	conflicting := make(map[string]bool)
+	for _, doc := range documents {
+		for _, f := range doc.Fragments {
+			if g.fragments[f.Name] != nil {
+				return nil, fmt.Errorf("%s: fragment %s is defined more than once", doc.path, f.Name)
+			}
+			g.fragments[f.Name] = f
+		}
+
+		for _, op := range doc.Operations {
+			for _, v := range op.Variables {
+				if other, exists := g.fragmentVariables[v.Name]; exists && other.Type.String() != v.Type.String() {
+					conflicting[v.Name] = true
+				}
+				g.fragmentVariables[v.Name] = v
+			}
+		}
+	}
+
+	for name := range conflicting {
+		delete(g.fragmentVariables, name)
+	}
+
+	for _, doc := range documents {
+		for _, op := range doc.Operations {
+			if err := g.operation(doc.path, op); err != nil {
+				return nil, fmt.Errorf("%s: %w", doc.path, err)
+			}
+		}
+	}
+
+	g.variables = g.fragmentVariables
+	for _, doc := range documents {
+		for _, f := range doc.Fragments {
+			if err := g.fragment(doc.path, f); err != nil {
+				return nil, fmt.Errorf("%s: fragment %s: %w", doc.path, f.Name, err)
+			}
+		}
+	}
+
+	// Input object types are generated once all of them are known, since they can refer to
+	// each other, and in the order of the schema.
+	for len(g.inputQueue) > 0 {
+		t := g.inputQueue[0]
+		g.inputQueue = g.inputQueue[1:]
+
+		src, err := g.input(t)
+		if err != nil {
+			return nil, fmt.Errorf("input type %s: %w", t.Name, err)
+		}
+		g.inputs[t.Name] = src
+	}
+
+	for _, t := range s.Types {
+		g.out.WriteString(g.inputs[t.Name])
+	}
+
+	for _, t := range s.Types {
+		if _, used := g.enums[t.Name]; used {
+			g.enum(t)
+		}
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by goqlgen. DO NOT EDIT.\n\npackage %s\n\n", pkg) //nolint:errcheck
+
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			imports = append(imports, strconv.Quote(path))
+		}
+		sort.Strings(imports)
+
+		fmt.Fprintf(&src, "import (\n%s\n)\n\n", strings.Join(imports, "\n")) //nolint:errcheck
+	}
+	src.Write(g.out.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated source: %w", err)
+	}
+	return formatted, nil
+}
+
+// declare declares the Go type with the given name for what, which describes the definition it
+// is generated for.
+func (g *generator) declare(name, what string) error {
+	if other, exists := g.declared[name]; exists {
+		return fmt.Errorf("cannot generate type %s for %s, it's already generated for %s", name, what, other)
+	}
+
+	g.declared[name] = what
+	return nil
+}
+
+// comment writes the doc comment of a declaration, followed by the description of the definition
+// it's generated for, if it has one.
+func (g *generator) comment(summary, description string) {
+	fmt.Fprintf(&g.out, "// %s\n", summary) //nolint:errcheck
+	if description != "" {
+		g.out.WriteString("//\n")
+		for _, line := range strings.Split(description, "\n") {
+			fmt.Fprintf(&g.out, "// %s\n", strings.TrimRight(line, " \t")) //nolint:errcheck
+		}
+	}
+}
+
+// operation generates the type of op and of its variables.
+func (g *generator) operation(path string, op *schema.OperationDefinition) error { //nolint:funlen
+	if op.Name == "" {
+		return fmt.Errorf("anonymous %s: operations must be named to generate types for them", op.Operation)
+	}
+
+	root := g.schema.RootType(op.Operation)
+	if root == nil {
+		return fmt.Errorf("%s %s: schema doesn't support %s operations", op.Operation, op.Name, op.Operation)
+	}
+
+	name := exported(op.Name)
+	if err := g.declare(name, op.Operation+" "+op.Name); err != nil {
+		return err
+	}
+
+	g.variables = make(map[string]*schema.VariableDefinition, len(op.Variables))
+	for _, v := range op.Variables {
+		g.variables[v.Name] = v
+	}
+
+	fields, err := g.selectionSet(root, op.SelectionSet)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", op.Operation, op.Name, err)
+	}
+
+	g.comment(fmt.Sprintf("%s is the %s %s, as defined in %s.", name, op.Name, op.Operation, filepath.Base(path)), "")
+	fmt.Fprintf(&g.out, "type %s %s\n\n", name, fields) //nolint:errcheck
+
+	g.out.WriteString("// OperationName implements goql.NamedOperation.\n")
+	fmt.Fprintf(&g.out, "func (%s) OperationName() string { return %q }\n\n", name, op.Name) //nolint:errcheck
+
+	if len(op.Variables) == 0 {
+		return nil
+	}
+
+	variables := name + "Variables"
+	if err := g.declare(variables, "the variables of "+op.Operation+" "+op.Name); err != nil {
+		return err
+	}
+
+	var decl, assign strings.Builder
+	names := make(map[string]int)
+	for _, v := range op.Variables {
+		typ, err := g.inputType(v.Type)
+		if err != nil {
+			return fmt.Errorf("%s %s: variable $%s: %w", op.Operation, op.Name, v.Name, err)
+		}
+
+		field := unique(names, exported(v.Name))
+		if v.Type.NonNull() {
+			fmt.Fprintf(&decl, "%s %s %s\n", field, typ, tag("", v.Name)) //nolint:errcheck
+			fmt.Fprintf(&assign, "variables[%q] = v.%s\n", v.Name, field) //nolint:errcheck
+			continue
+		}
+
+		fmt.Fprintf(&decl, "%s %s %s\n", field, typ, tag("", v.Name+",omitempty"))                //nolint:errcheck
+		fmt.Fprintf(&assign, "if v.%s != nil {\nvariables[%q] = v.%s\n}\n", field, v.Name, field) //nolint:errcheck
+	}
+
+	g.comment(fmt.Sprintf("%s holds onto the variables of the %s %s.", variables, op.Name, op.Operation), "")
+	fmt.Fprintf(&g.out, "type %s struct {\n%s}\n\n", variables, decl.String()) //nolint:errcheck
+
+	g.out.WriteString("// Map returns the variables in the form expected by goql.Operation. Nil variables are left\n")
+	g.out.WriteString("// out, which makes their default values apply.\n")
+	fmt.Fprintf(&g.out, "func (v %s) Map() map[string]interface{} {\n", variables)            //nolint:errcheck
+	fmt.Fprintf(&g.out, "variables := make(map[string]interface{}, %d)\n", len(op.Variables)) //nolint:errcheck
+	fmt.Fprintf(&g.out, "%sreturn variables\n}\n\n", assign.String())                         //nolint:errcheck
+
+	return nil
+}
+
+// fragment generates the type of the named fragment f.
+func (g *generator) fragment(path string, f *schema.FragmentDefinition) error {
+	on := g.schema.Type(f.TypeCondition)
+	if on == nil || !on.IsComposite() {
+		return fmt.Errorf("type condition %s isn't an object, interface, or union type", f.TypeCondition)
+	}
+
+	name := exported(f.Name)
+	if err := g.declare(name, "fragment "+f.Name); err != nil {
+		return err
+	}
+
+	fields, err := g.selectionSet(on, f.SelectionSet)
+	if err != nil {
+		return err
+	}
+
+	g.comment(fmt.Sprintf("%s is the %s fragment on %s, as defined in %s.", name, f.Name, f.TypeCondition, filepath.Base(path)), "")
+	fmt.Fprintf(&g.out, "type %s %s\n\n", name, fields) //nolint:errcheck
+
+	g.out.WriteString("// FragmentName implements goql.Fragment.\n")
+	fmt.Fprintf(&g.out, "func (%s) FragmentName() string { return %q }\n\n", name, f.Name) //nolint:errcheck
+
+	g.out.WriteString("// FragmentOn implements goql.Fragment.\n")
+	fmt.Fprintf(&g.out, "func (%s) FragmentOn() string { return %q }\n\n", name, f.TypeCondition) //nolint:errcheck
+
+	return nil
+}
+
+// selectionSet returns the Go struct type that holds onto the data of the given selections on
+// the type parent.
+func (g *generator) selectionSet(parent *schema.Type, selections []*schema.Selection) (string, error) { //nolint:funlen
+	var b strings.Builder
+	b.WriteString("struct {\n")
+
+	names := make(map[string]int)
+	for _, sel := range selections {
+		directives, err := g.directives(sel.Directives)
+		if err != nil {
+			return "", err
+		}
+
+		switch sel.Kind {
+		case schema.SelectionField:
+			var t *schema.TypeRef
+			if sel.Name == "__typename" {
+				t = schema.NonNullOf(schema.Named("String"))
+			} else if def := parent.Field(sel.Name); def != nil {
+				t = def.Type
+			} else {
+				return "", fmt.Errorf("unknown field %q on type %s", sel.Name, parent.Name)
+			}
+
+			// Fields that are included or skipped conditionally may be missing from the
+			// response, which is told apart by them being nil.
+			if conditional(sel.Directives) {
+				t = t.Nullable()
+			}
+
+			typ, err := g.outputType(t, sel)
+			if err != nil {
+				return "", fmt.Errorf("field %s: %w", sel.ResponseKey(), err)
+			}
+
+			decl := sel.Name
+			if len(sel.Arguments) > 0 {
+				args, err := g.arguments(sel.Arguments)
+				if err != nil {
+					return "", fmt.Errorf("field %s: %w", sel.ResponseKey(), err)
+				}
+				decl += "(" + args + ")"
+			}
+
+			items := []string{decl}
+			if sel.Alias != "" {
+				items = append(items, "@alias("+sel.Alias+")")
+			}
+			items = append(items, directives...)
+
+			key := sel.ResponseKey()
+			fmt.Fprintf(&b, "%s %s %s\n", unique(names, exported(key)), typ, tag(strings.Join(items, ","), key)) //nolint:errcheck
+		case schema.SelectionInlineFragment:
+			condition := sel.TypeCondition
+			if condition == "" {
+				condition = parent.Name
+			}
+
+			on := g.schema.Type(condition)
+			if on == nil || !on.IsComposite() {
+				return "", fmt.Errorf("inline fragment on %s, which isn't an object, interface, or union type", condition)
+			}
+
+			typ, err := g.selectionSet(on, sel.SelectionSet)
+			if err != nil {
+				return "", fmt.Errorf("inline fragment on %s: %w", condition, err)
+			}
+
+			items := append([]string{"... on " + condition}, directives...)
+			fmt.Fprintf(&b, "%s %s %s\n", unique(names, "On"+exported(condition)), typ, tag(strings.Join(items, ","), "-")) //nolint:errcheck
+		case schema.SelectionFragmentSpread:
+			if g.fragments[sel.Name] == nil {
+				return "", fmt.Errorf("unknown fragment %s", sel.Name)
+			}
+
+			// Named fragments are embedded, which spreads them in place.
+			b.WriteString(exported(sel.Name))
+			if len(directives) > 0 {
+				fmt.Fprintf(&b, " %s", tag(strings.Join(directives, ","), "")) //nolint:errcheck
+			}
+			b.WriteByte('\n')
+		}
+	}
+
+	b.WriteString("}")
+	return b.String(), nil
+}
+
+// outputType returns the Go type that holds onto the data of the selection sel, a field of type
+// t.
+func (g *generator) outputType(t *schema.TypeRef, sel *schema.Selection) (string, error) {
+	nonNull := t.NonNull()
+	t = t.Nullable()
+
+	if t.Kind == schema.KindList {
+		elem, err := g.outputType(t.OfType, sel)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	}
+
+	named := g.schema.Type(t.Name)
+	if named == nil {
+		return "", fmt.Errorf("unknown type %s", t.Name)
+	}
+
+	if !named.IsComposite() && len(sel.SelectionSet) > 0 {
+		return "", fmt.Errorf("cannot select fields on leaf type %s", named.Name)
+	}
+
+	var typ string
+	var nilable bool
+
+	switch named.Kind { //nolint:exhaustive // Why: input object types are never the types of fields.
+	case schema.KindObject, schema.KindInterface, schema.KindUnion:
+		if len(sel.SelectionSet) == 0 {
+			return "", fmt.Errorf("must select fields on type %s", named.Name)
+		}
+
+		var err error
+		if typ, err = g.selectionSet(named, sel.SelectionSet); err != nil {
+			return "", err
+		}
+	case schema.KindEnum:
+		typ = g.useEnum(named)
+	case schema.KindScalar:
+		scalar := g.scalar(named.Name)
+		typ, nilable = scalar.name, scalar.nilable
+	default:
+		return "", fmt.Errorf("type %s cannot be selected", named.Name)
+	}
+
+	if !nonNull && !nilable {
+		typ = "*" + typ
+	}
+	return typ, nil
+}
+
+// inputType returns the Go type that holds onto values of the input type t, e.g. variables.
+func (g *generator) inputType(t *schema.TypeRef) (string, error) {
+	nonNull := t.NonNull()
+	t = t.Nullable()
+
+	if t.Kind == schema.KindList {
+		elem, err := g.inputType(t.OfType)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	}
+
+	named := g.schema.Type(t.Name)
+	if named == nil {
+		return "", fmt.Errorf("unknown type %s", t.Name)
+	}
+
+	var typ string
+	var nilable bool
+
+	switch named.Kind { //nolint:exhaustive // Why: only scalar, enum, and input object types are input types.
+	case schema.KindInputObject:
+		typ = g.useInput(named)
+	case schema.KindEnum:
+		typ = g.useEnum(named)
+	case schema.KindScalar:
+		scalar := g.scalar(named.Name)
+		typ, nilable = scalar.name, scalar.nilable
+	default:
+		return "", fmt.Errorf("type %s isn't an input type", named.Name)
+	}
+
+	if !nonNull && !nilable {
+		typ = "*" + typ
+	}
+	return typ, nil
+}
+
+// scalar returns the Go type of the scalar type with the given name, importing its package.
+func (g *generator) scalar(name string) goType {
+	scalar, exists := g.scalars[name]
+	if !exists {
+		if scalar, exists = builtinScalars[name]; !exists {
+			scalar = rawMessage
+		}
+	}
+
+	if scalar.importPath != "" {
+		g.imports[scalar.importPath] = true
+	}
+	return scalar
+}
+
+// useEnum returns the name of the Go type of the enum type t, which is generated once all of the
+// operations were.
+func (g *generator) useEnum(t *schema.Type) string {
+	g.enums[t.Name] = ""
+	return exported(t.Name)
+}
+
+// useInput returns the name of the Go type of the input object type t, which is generated once
+// all of the operations were.
+func (g *generator) useInput(t *schema.Type) string {
+	if _, used := g.inputs[t.Name]; !used {
+		g.inputs[t.Name] = ""
+		g.inputQueue = append(g.inputQueue, t)
+	}
+	return exported(t.Name)
+}
+
+// input returns the declaration of the Go type of the input object type t.
+func (g *generator) input(t *schema.Type) (string, error) {
+	name := exported(t.Name)
+	if err := g.declare(name, "input type "+t.Name); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	names := make(map[string]int)
+	for _, f := range t.InputFields {
+		typ, err := g.inputType(f.Type)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		key := f.Name
+		if !f.Type.NonNull() {
+			key += ",omitempty"
+		}
+		fmt.Fprintf(&b, "%s %s %s\n", unique(names, exported(f.Name)), typ, tag("", key)) //nolint:errcheck
+	}
+
+	// The declaration is written aside, since input types are written in the order of the
+	// schema.
+	out := g.out
+	g.out = bytes.Buffer{}
+	defer func() { g.out = out }()
+
+	g.comment(fmt.Sprintf("%s is the %s input type.", name, t.Name), t.Description)
+	fmt.Fprintf(&g.out, "type %s struct {\n%s}\n\n", name, b.String()) //nolint:errcheck
+
+	return g.out.String(), nil
+}
+
+// enum writes the declaration of the Go type of the enum type t and of its values.
+func (g *generator) enum(t *schema.Type) {
+	name := exported(t.Name)
+	if err := g.declare(name, "enum "+t.Name); err != nil {
+		// The enum type is already declared by the input or operation type of the same name,
+		// which fails to compile and tells the user as much.
+		fmt.Fprintf(&g.out, "// %v\n", err) //nolint:errcheck
+	}
+
+	g.comment(fmt.Sprintf("%s is the %s enum type.", name, t.Name), t.Description)
+	fmt.Fprintf(&g.out, "type %s string\n\n", name) //nolint:errcheck
+
+	fmt.Fprintf(&g.out, "// Values of the %s enum type.\nconst (\n", t.Name) //nolint:errcheck
+	names := make(map[string]int)
+	for _, v := range t.EnumValues {
+		if v.Description != "" {
+			fmt.Fprintf(&g.out, "// %s\n", strings.ReplaceAll(v.Description, "\n", "\n// ")) //nolint:errcheck
+		}
+
+		if v.IsDeprecated {
+			if v.Description != "" {
+				g.out.WriteString("//\n")
+			}
+			fmt.Fprintf(&g.out, "// Deprecated: %s\n", v.DeprecationReason) //nolint:errcheck
+		}
+		fmt.Fprintf(&g.out, "%s %s = %q\n", unique(names, name+exported(v.Name)), name, v.Name) //nolint:errcheck
+	}
+	g.out.WriteString(")\n\n")
+}
+
+// arguments returns the arguments passed to a field or directive written in the syntax of goql
+// struct tags, e.g. id:$id<ID!>,first:10.
+func (g *generator) arguments(args []*schema.Argument) (string, error) {
+	written := make([]string, 0, len(args))
+	for _, arg := range args {
+		value, err := g.value(arg.Value)
+		if err != nil {
+			return "", fmt.Errorf("argument %s: %w", arg.Name, err)
+		}
+		written = append(written, arg.Name+":"+value)
+	}
+	return strings.Join(written, ","), nil
+}
+
+// value returns v written in the syntax of goql struct tags, which declares the type and the
+// default value of variables wherever they're used, e.g. $first<Int>=10.
+func (g *generator) value(v *schema.Value) (string, error) {
+	switch v.Kind { //nolint:exhaustive // Why: any other value is written as is.
+	case schema.ValueVariable:
+		def := g.variables[v.Raw]
+		if def == nil {
+			return "", fmt.Errorf("undefined variable $%s", v.Raw)
+		}
+
+		written := fmt.Sprintf("$%s<%s>", def.Name, def.Type)
+		if def.DefaultValue != nil {
+			defaultValue, err := g.value(def.DefaultValue)
+			if err != nil {
+				return "", err
+			}
+			written += "=" + defaultValue
+		}
+		return written, nil
+	case schema.ValueList:
+		items := make([]string, 0, len(v.List))
+		for _, item := range v.List {
+			written, err := g.value(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, written)
+		}
+		return "[" + strings.Join(items, ",") + "]", nil
+	case schema.ValueObject:
+		fields, err := g.arguments(v.Fields)
+		if err != nil {
+			return "", err
+		}
+		return "{" + fields + "}", nil
+	default:
+		return v.String(), nil
+	}
+}
+
+// directives returns the given directives written in the syntax of goql struct tags.
+func (g *generator) directives(directives []*schema.AppliedDirective) ([]string, error) {
+	written := make([]string, 0, len(directives))
+	for _, d := range directives {
+		switch d.Name {
+		case "include", "skip":
+			// goql takes the condition of include and skip directives as is, and declares
+			// variables used as conditions as Boolean! itself.
+			condition := d.Argument("if")
+			if condition == nil {
+				return nil, fmt.Errorf("directive @%s: missing argument if", d.Name)
+			}
+
+			if condition.Kind == schema.ValueVariable && g.variables[condition.Raw] == nil {
+				return nil, fmt.Errorf("directive @%s: undefined variable $%s", d.Name, condition.Raw)
+			}
+			written = append(written, fmt.Sprintf("@%s(%s)", d.Name, condition))
+		default:
+			if len(d.Arguments) == 0 {
+				written = append(written, "@"+d.Name)
+				continue
+			}
+
+			args, err := g.arguments(d.Arguments)
+			if err != nil {
+				return nil, fmt.Errorf("directive @%s: %w", d.Name, err)
+			}
+			written = append(written, fmt.Sprintf("@%s(%s)", d.Name, args))
+		}
+	}
+	return written, nil
+}
+
+// conditional denotes whether or not the given directives include or skip what they're applied to
+// conditionally.
+func conditional(directives []*schema.AppliedDirective) bool {
+	for _, d := range directives {
+		if d.Name == "include" || d.Name == "skip" {
+			return true
+		}
+	}
+	return false
+}
+
+// tag returns a struct tag with the given goql and json tags, either of which may be empty.
+func tag(goql, json string) string {
+	var tags []string
+	if goql != "" {
+		tags = append(tags, "goql:"+strconv.Quote(goql))
+	}
+	if json != "" {
+		tags = append(tags, "json:"+strconv.Quote(json))
+	}
+
+	t := strings.Join(tags, " ")
+	if strings.Contains(t, "`") {
+		return strconv.Quote(t)
+	}
+	return "`" + t + "`"
+}
+
+// unique returns name, followed by a number if it's already in names, and adds it to names.
+func unique(names map[string]int, name string) string {
+	names[name]++
+	if n := names[name]; n > 1 {
+		return name + strconv.Itoa(n)
+	}
+	return name
+}
+
+// initialisms are the words that are written in all caps in Go names, e.g. ID rather than Id.
+var initialisms = map[string]bool{
+	"API": true, "CSS": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "UI": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// exported returns the exported Go name of the GraphQL name, e.g. UserID for userId or
+// InProgress for IN_PROGRESS.
+func exported(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		for _, word := range words(part) {
+			if upper := strings.ToUpper(word); initialisms[upper] {
+				b.WriteString(upper)
+				continue
+			}
+			b.WriteString(strings.ToUpper(word[:1]) + strings.ToLower(word[1:]))
+		}
+	}
+
+	if b.Len() == 0 || !unicode.IsLetter(rune(b.String()[0])) {
+		return "X" + b.String()
+	}
+	return b.String()
+}
+
+// words splits a camel cased name into its words, e.g. HTML and Parser for HTMLParser.
+func words(name string) []string {
+	var split []string
+
+	start := 0
+	for i := 1; i < len(name); i++ {
+		prev, cur := rune(name[i-1]), rune(name[i])
+		next := rune(0)
+		if i+1 < len(name) {
+			next = rune(name[i+1])
+		}
+
+		lowerToUpper := unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev))
+		acronymEnd := unicode.IsUpper(cur) && unicode.IsUpper(prev) && unicode.IsLower(next)
+		if lowerToUpper || acronymEnd {
+			split = append(split, name[start:i])
+			start = i
+		}
+	}
+
+	if start < len(name) {
+		split = append(split, name[start:])
+	}
+	return split
+}
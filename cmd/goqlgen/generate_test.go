@@ -0,0 +1,185 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getoutreach/goql/schema"
+	"github.com/google/go-cmp/cmp"
+)
+
+// generateSDL is the schema that types are generated against in TestGenerate.
+const generateSDL = `
+scalar DateTime
+
+type Query {
+  user(id: ID!): User
+  users(filter: UserFilter, first: Int): [User!]!
+  node(id: ID!): Node
+}
+
+interface Node {
+  id: ID!
+}
+
+type User implements Node {
+  id: ID!
+  name: String
+  role: Role!
+  createdAt: DateTime
+  friends(first: Int!): [User]
+}
+
+"""The role of a user."""
+enum Role {
+  ADMIN
+  IN_PROGRESS @deprecated(reason: "Use ADMIN.")
+}
+
+input UserFilter {
+  role: Role
+  names: [String!]
+  team: TeamFilter
+}
+
+input TeamFilter {
+  name: String!
+}
+`
+
+// TestGenerate compares the source generated for each test case that's expected to succeed with
+// testdata/<Name>.golden.
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	s, err := schema.ParseSDL(generateSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		Name        string
+		Input       string
+		Scalars     map[string]string
+		ExpectedErr string
+	}{
+		{
+			Name: "Query",
+			Input: `query GetUser($id: ID!, $withRole: Boolean!, $first: Int = 5) {
+  user(id: $id) {
+    ...UserFields
+    role @include(if: $withRole)
+    friends(first: $first) { userId: id }
+  }
+}
+
+fragment UserFields on User {
+  id
+  name
+}`,
+		},
+		{
+			Name: "InputsEnumsAndScalars",
+			Input: `query ListUsers($filter: UserFilter) {
+  users(filter: $filter, first: 10) {
+    createdAt
+  }
+}`,
+			Scalars: map[string]string{"DateTime": "time.Time"},
+		},
+		{
+			Name: "InlineFragments",
+			Input: `query GetNode {
+  node(id: "1") {
+    __typename
+    ... on User { name }
+  }
+}`,
+		},
+		{
+			Name:        "AnonymousOperation",
+			Input:       `{ user(id: "1") { id } }`,
+			ExpectedErr: "operations.graphql: anonymous query: operations must be named to generate types for them",
+		},
+		{
+			Name:        "UnknownField",
+			Input:       `query GetUser { user(id: "1") { email } }`,
+			ExpectedErr: `operations.graphql: query GetUser: field user: unknown field "email" on type User`,
+		},
+		{
+			Name:        "UndefinedVariable",
+			Input:       `query GetUser { user(id: $id) { id } }`,
+			ExpectedErr: "operations.graphql: query GetUser: field user: argument id: undefined variable $id",
+		},
+		{
+			Name:        "LeafSelection",
+			Input:       `query GetUser { user(id: "1") }`,
+			ExpectedErr: "operations.graphql: query GetUser: field user: must select fields on type User",
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			doc, err := schema.ParseQuery(test.Input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			src, err := generate(s, "operations", test.Scalars, []document{{path: "operations.graphql", QueryDocument: doc}})
+			if test.ExpectedErr != "" {
+				if err == nil || err.Error() != test.ExpectedErr {
+					t.Fatalf("expected error %q, got %v", test.ExpectedErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected, err := os.ReadFile(filepath.Join("testdata", test.Name+".golden"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(string(expected), string(src)); diff != "" {
+				t.Errorf("generated source (-want +got):\n%s", diff)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestExported(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		Input    string
+		Expected string
+	}{
+		{"id", "ID"},
+		{"userId", "UserID"},
+		{"createdAt", "CreatedAt"},
+		{"IN_PROGRESS", "InProgress"},
+		{"HTMLParser", "HTMLParser"},
+		{"api_url", "APIURL"},
+		{"__typename", "Typename"},
+		{"_1", "X1"},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			if got := exported(test.Input); got != test.Expected {
+				t.Errorf("expected %q, got %q", test.Expected, got)
+			}
+		}
+
+		t.Run(test.Input, fn)
+	}
+}
@@ -0,0 +1,113 @@
+// Command goqlgen generates the Go types of the GraphQL operations defined in .graphql files,
+// tagged to be marshaled and decoded by goql. It reads the schema of the GraphQL server from a
+// file, saved e.g. with goql.Client.Introspect and schema.Schema.Save, and never accesses the
+// network, which makes it suitable to run under go generate:
+//
+//	//go:generate go run github.com/getoutreach/goql/cmd/goqlgen -schema schema.graphql -out operations_gen.go operations.graphql
+//
+// For each named operation it generates a struct type that implements goql.NamedOperation,
+// with a field for each of the fields it selects, and a struct type for its variables whose Map
+// method returns them in the form expected by goql.Operation. Nullable fields are pointers, and
+// the enum and input object types that the operations use are generated along with them. Named
+// fragments are generated as struct types that implement goql.Fragment, which are embedded
+// wherever they're spread.
+//
+// Usage:
+//
+//	goqlgen -schema <file> [-package <name>] [-out <file>] [-scalar <name>=<type>]... <file>...
+//
+// The package defaults to $GOPACKAGE, which go generate sets, and the output file to
+// goql_gen.go. Custom scalars are decoded into json.RawMessage unless they're mapped to a Go
+// type with -scalar, e.g. -scalar DateTime=time.Time or -scalar
+// Decimal=github.com/shopspring/decimal.Decimal.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/getoutreach/goql/schema"
+)
+
+// scalarFlags collects the -scalar flags.
+type scalarFlags map[string]string
+
+// String implements flag.Value.
+func (s scalarFlags) String() string {
+	mappings := make([]string, 0, len(s))
+	for name, goType := range s {
+		mappings = append(mappings, name+"="+goType)
+	}
+	return strings.Join(mappings, ",")
+}
+
+// Set implements flag.Value.
+func (s scalarFlags) Set(value string) error {
+	name, goType, ok := strings.Cut(value, "=")
+	if !ok || name == "" || goType == "" {
+		return fmt.Errorf("expected <name>=<type>, got %q", value)
+	}
+
+	s[name] = goType
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "goqlgen: %v\n", err) //nolint:errcheck
+		os.Exit(1)
+	}
+}
+
+// run runs goqlgen with the given command-line arguments.
+func run(args []string) error {
+	fs := flag.NewFlagSet("goqlgen", flag.ContinueOnError)
+
+	schemaPath := fs.String("schema", "", "path to the schema, either an SDL document or an introspection result in JSON")
+	pkg := fs.String("package", os.Getenv("GOPACKAGE"), "name of the package of the generated file, defaults to $GOPACKAGE")
+	out := fs.String("out", "goql_gen.go", "path to the generated file")
+
+	scalars := make(scalarFlags)
+	fs.Var(scalars, "scalar", "Go type of a custom scalar, e.g. DateTime=time.Time, may be repeated")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch {
+	case *schemaPath == "":
+		return fmt.Errorf("missing -schema")
+	case *pkg == "":
+		return fmt.Errorf("missing -package, which defaults to $GOPACKAGE when run by go generate")
+	case fs.NArg() == 0:
+		return fmt.Errorf("missing .graphql files to generate types from")
+	}
+
+	s, err := schema.Load(*schemaPath)
+	if err != nil {
+		return err
+	}
+
+	var documents []document
+	for _, path := range fs.Args() {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		doc, err := schema.ParseQuery(string(b))
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		documents = append(documents, document{path: path, QueryDocument: doc})
+	}
+
+	src, err := generate(s, *pkg, scalars, documents)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(*out, src, 0o644) //nolint:gosec // Why: generated source files are meant to be readable.
+}
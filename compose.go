@@ -0,0 +1,171 @@
+package goql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// composedAliasPrefix returns the prefix of the aliases given to the root fields of the i-th
+// operation of a composed operation, which is how the data of each of them is told apart in the
+// response.
+func composedAliasPrefix(i int) string {
+	return fmt.Sprintf("op%d_", i)
+}
+
+// composed is an operation composed of the root fields of several operations.
+type composed struct {
+	Query     string
+	Variables map[string]interface{}
+}
+
+// compose marshals the given operations into a single operation of the given type ("query" or
+// "mutation") that selects the root fields of all of them. The root fields of each operation
+// are aliased with a prefix unique to it, and the variables of each operation are renamed if
+// their names are already used by one of the operations before it.
+func compose(operations []*Operation, wrapper string, tagParse tagParser) (composed, error) { //nolint:funlen
+	var body strings.Builder
+	w := writer{Writer: &body, document: &document{}}
+
+	result := composed{
+		Variables: make(map[string]interface{}),
+	}
+
+	// used holds onto the names of the variables taken by the operations composed so far.
+	used := make(map[string]bool)
+
+	io.WriteString(w, " {\n") //nolint:errcheck
+	for i, operation := range operations {
+		tree, err := build(operation.OperationType, tagParse)
+		if err != nil {
+			return composed{}, err
+		}
+
+		tokens := tree.tokens()
+		if _, err := argsFromTokens(tokens); err != nil {
+			return composed{}, err
+		}
+
+		own := make(map[string]bool, len(tokens))
+		for _, t := range tokens {
+			own[t.Arg] = true
+		}
+
+		// Rename the variables whose names are taken, to names that aren't taken by any other
+		// variable either, and keep the values of the variables that the operation actually
+		// uses under their new names.
+		names := make(map[string]string)
+		for _, t := range tokens {
+			if _, seen := names[t.Arg]; seen {
+				continue
+			}
+
+			name := t.Arg
+			for n := 1; used[name] || (name != t.Arg && own[name]); n++ {
+				name = fmt.Sprintf("%s_%d", t.Arg, n)
+			}
+			names[t.Arg] = name
+			used[name] = true
+
+			if value, exists := operation.Variables[t.Arg]; exists {
+				result.Variables[name] = value
+			}
+		}
+
+		root := tree.renamed(names)
+		prefix := composedAliasPrefix(i)
+		for j := range root.Fields {
+			decl := &root.Fields[j].Decl
+			if decl.TypeCondition != "" {
+				return composed{}, fmt.Errorf("cannot compose operation %T with a fragment at its root", operation.OperationType)
+			}
+
+			if decl.Alias == "" {
+				decl.Alias = decl.Name
+			}
+			decl.Alias = prefix + decl.Alias
+		}
+
+		if err := root.tokenizeChildren(&w, operation.Fields); err != nil {
+			return composed{}, err
+		}
+	}
+	io.WriteString(w, "}") //nolint:errcheck
+
+	// The header of the operation is built from the variables of the fields that were rendered,
+	// which already carry their new names.
+	args, err := argsFromTokens(w.variables)
+	if err != nil {
+		return composed{}, err
+	}
+
+	var b strings.Builder
+	io.WriteString(&b, wrapper) //nolint:errcheck
+	if len(args) > 0 {
+		fmt.Fprintf(&b, "(%s)", strings.Join(args, ", ")) //nolint:errcheck
+	}
+	io.WriteString(&b, body.String()) //nolint:errcheck
+	w.tokenizeDefinitions(&b)
+
+	result.Query = b.String()
+	return result, nil
+}
+
+// decomposed splits the "data" key of the response to a composed operation into the data of
+// each of the operations it was composed of.
+func decomposed(data json.RawMessage, operations int) ([]json.RawMessage, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+
+	parts := make([]map[string]json.RawMessage, operations)
+	for i := range parts {
+		parts[i] = make(map[string]json.RawMessage)
+	}
+
+	for key, value := range object {
+		for i := range parts {
+			if prefix := composedAliasPrefix(i); strings.HasPrefix(key, prefix) {
+				parts[i][strings.TrimPrefix(key, prefix)] = value
+				break
+			}
+		}
+	}
+
+	result := make([]json.RawMessage, operations)
+	for i := range parts {
+		b, err := json.Marshal(parts[i])
+		if err != nil {
+			return nil, err
+		}
+		result[i] = b
+	}
+
+	return result, nil
+}
+
+// renamed returns a deep copy of the receiver field with the variables used throughout it
+// renamed according to names, which maps the old names to the new ones.
+func (f *field) renamed(names map[string]string) field {
+	nf := *f
+	nf.Decl.Arguments = f.Decl.Arguments.renamed(names)
+
+	nf.Directives = make([]directive, len(f.Directives))
+	for i, d := range f.Directives {
+		if name, exists := names[d.Token.Arg]; exists && (d.Token != token{}) {
+			d.Token.Arg = name
+			d.Template = "$" + name
+		}
+		d.Arguments = d.Arguments.renamed(names)
+		nf.Directives[i] = d
+	}
+
+	nf.Fields = make([]field, len(f.Fields))
+	for i := range f.Fields {
+		nf.Fields[i] = f.Fields[i].renamed(names)
+	}
+
+	return nf
+}
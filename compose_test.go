@@ -0,0 +1,250 @@
+package goql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// TestCompose tests the compose function.
+func TestCompose(t *testing.T) {
+	t.Parallel()
+
+	type GetUser struct {
+		User struct {
+			ID   string
+			Name string
+		} `goql:"user(id:$id<ID!>)"`
+	}
+
+	type GetAccount struct {
+		Account struct {
+			ID   string
+			Logo string `goql:"logo(size:$size<Int>=64)"`
+		} `goql:"account(id:$id<ID!>),@alias(org)"`
+	}
+
+	type GetFlagged struct {
+		Users []struct {
+			ID string
+		} `goql:"users(ids:[$id<ID!>, $id_1<ID!>]),@include($withUsers)"`
+	}
+
+	tt := []struct {
+		Name              string
+		Operations        []*Operation
+		ExpectedQuery     string
+		ExpectedVariables map[string]interface{}
+	}{
+		{
+			Name: "Single",
+			Operations: []*Operation{
+				{OperationType: &GetUser{}, Variables: map[string]interface{}{"id": "1", "unused": true}},
+			},
+			ExpectedQuery:     "query($id: ID!) {\nop0_user: user(id: $id) {\nid\nname\n}\n}",
+			ExpectedVariables: map[string]interface{}{"id": "1"},
+		},
+		{
+			Name: "CollidingVariables",
+			Operations: []*Operation{
+				{OperationType: &GetUser{}, Variables: map[string]interface{}{"id": "1"}},
+				{OperationType: &GetAccount{}, Variables: map[string]interface{}{"id": "2"}},
+				{OperationType: &GetUser{}, Variables: map[string]interface{}{"id": "3"}},
+			},
+			ExpectedQuery: "query($id: ID!, $id_1: ID!, $size: Int = 64, $id_2: ID!) {\nop0_user: user(id: $id) {\nid\nname\n}\n" +
+				"op1_org: account(id: $id_1) {\nid\nlogo(size: $size)\n}\nop2_user: user(id: $id_2) {\nid\nname\n}\n}",
+			ExpectedVariables: map[string]interface{}{"id": "1", "id_1": "2", "id_2": "3"},
+		},
+		{
+			Name: "RenamedAroundOwnVariables",
+			Operations: []*Operation{
+				{OperationType: &GetUser{}, Variables: map[string]interface{}{"id": "1"}},
+				{OperationType: &GetFlagged{}, Variables: map[string]interface{}{"id": "2", "id_1": "3", "withUsers": false}},
+			},
+			ExpectedQuery: "query($id: ID!, $id_2: ID!, $id_1: ID!, $withUsers: Boolean!) {\nop0_user: user(id: $id) {\nid\nname\n}\n" +
+				"op1_users: users(ids: [$id_2, $id_1]) @include(if: $withUsers) {\nid\n}\n}",
+			ExpectedVariables: map[string]interface{}{"id": "1", "id_2": "2", "id_1": "3", "withUsers": false},
+		},
+		{
+			Name: "SparseFields",
+			Operations: []*Operation{
+				{OperationType: &GetUser{}, Fields: Fields{"name": true}, Variables: map[string]interface{}{"id": "1"}},
+				{OperationType: &GetAccount{}, Fields: Fields{"id": true}, Variables: map[string]interface{}{"id": "2"}},
+			},
+			ExpectedQuery:     "query($id: ID!, $id_1: ID!) {\nop0_user: user(id: $id) {\nname\n}\nop1_org: account(id: $id_1) {\nid\n}\n}",
+			ExpectedVariables: map[string]interface{}{"id": "1", "id_1": "2"},
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			composed, err := compose(test.Operations, "query", parseTag)
+			if err != nil {
+				t.Fatalf("error composing operations: %v", err)
+			}
+
+			if e, a := test.ExpectedQuery, composed.Query; e != a {
+				t.Errorf("expected query to be:\n%s\ngot:\n%s", e, a)
+			}
+
+			if e, a := test.ExpectedVariables, composed.Variables; !reflect.DeepEqual(e, a) {
+				t.Errorf("expected variables to be %v, got %v", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
+
+// TestQueryAll tests that the data of each of the operations composed by QueryAll is decoded
+// into its own OperationType.
+func TestQueryAll(t *testing.T) {
+	t.Parallel()
+
+	type GetUser struct {
+		User struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `goql:"user(id:$id<ID!>)" json:"user"`
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body request
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("error decoding request: %v", err)
+		}
+
+		w.Header().Set("Content-Type", applicationJSON)
+		io.WriteString(w, `{"data":{"op0_user":{"id":"`+body.Variables["id"].(string)+`","name":"Alice"},`+ //nolint:errcheck
+			`"op1_user":{"id":"`+body.Variables["id_1"].(string)+`","name":"Bob"}}}`)
+	}))
+	t.Cleanup(ts.Close)
+
+	client := NewClient(ts.URL, DefaultClientOptions)
+
+	var alice, bob GetUser
+	if err := client.QueryAll(context.Background(),
+		&Operation{OperationType: &alice, Variables: map[string]interface{}{"id": "1"}},
+		&Operation{OperationType: &bob, Variables: map[string]interface{}{"id": "2"}},
+	); err != nil {
+		t.Fatalf("error performing queries: %v", err)
+	}
+
+	if e, a := "1", alice.User.ID; e != a {
+		t.Errorf("expected id of first user to be %q, got %q", e, a)
+	}
+
+	if e, a := "Alice", alice.User.Name; e != a {
+		t.Errorf("expected name of first user to be %q, got %q", e, a)
+	}
+
+	if e, a := "2", bob.User.ID; e != a {
+		t.Errorf("expected id of second user to be %q, got %q", e, a)
+	}
+
+	if e, a := "Bob", bob.User.Name; e != a {
+		t.Errorf("expected name of second user to be %q, got %q", e, a)
+	}
+}
+
+// TestQueryAllNoOperations tests that QueryAll doesn't send anything without any operations.
+func TestQueryAllNoOperations(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	t.Cleanup(ts.Close)
+
+	client := NewClient(ts.URL, DefaultClientOptions)
+
+	if err := client.QueryAll(context.Background()); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if a := atomic.LoadInt32(&requests); a != 0 {
+		t.Errorf("expected no requests, got %d", a)
+	}
+}
+
+// TestQueryAllPartialDataDecodeError tests that errors decoding partial data of composed
+// operations are returned along with the errors returned in the response.
+func TestQueryAllPartialDataDecodeError(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", applicationJSON)
+		io.WriteString(w, `{"data":{"op0_user":{"id":1}},"errors":[{"message":"name unavailable"}]}`) //nolint:errcheck
+	}))
+	t.Cleanup(ts.Close)
+
+	client := NewClient(ts.URL, ClientOptions{AllowPartialData: true})
+
+	var q struct {
+		User struct {
+			ID   string
+			Name *string
+		}
+	}
+
+	err := client.QueryAll(context.Background(), &Operation{OperationType: &q})
+
+	var errs Errors
+	if !errors.As(err, &errs) || errs[0].Message != "name unavailable" {
+		t.Errorf("expected the errors of the response, got %v", err)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Errorf("expected the error of decoding the data, got %v", err)
+	}
+}
+
+// TestQueryAllFormat tests that composed operations are formatted by the marshal options of the
+// client, like any other operation.
+func TestQueryAllFormat(t *testing.T) {
+	t.Parallel()
+
+	type GetUser struct {
+		User struct {
+			ID string `json:"id"`
+		} `goql:"user(id:$id<ID!>)" json:"user"`
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body request
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("error decoding request: %v", err)
+		}
+
+		if e, a := `query($id:ID!){op0_user:user(id:$id){id}}`, body.Query; e != a {
+			t.Errorf("expected query to be %s, got %s", e, a)
+		}
+
+		w.Header().Set("Content-Type", applicationJSON)
+		io.WriteString(w, `{"data":{"op0_user":{"id":"1"}}}`) //nolint:errcheck
+	}))
+	t.Cleanup(ts.Close)
+
+	client := NewClient(ts.URL, DefaultClientOptions)
+	client.marshalOpts = append(client.marshalOpts, OptMinify)
+
+	var user GetUser
+	if err := client.QueryAll(context.Background(),
+		&Operation{OperationType: &user, Variables: map[string]interface{}{"id": "1"}},
+	); err != nil {
+		t.Fatalf("error performing queries: %v", err)
+	}
+
+	if e, a := "1", user.User.ID; e != a {
+		t.Errorf("expected id to be %q, got %q", e, a)
+	}
+}
@@ -0,0 +1,228 @@
+package goql
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// fragmentCache stores whether or not a type contains inline fragments anywhere within it,
+// keyed by its reflect.Type.
+var fragmentCache sync.Map
+
+// unmarshalerType is the reflect.Type of the json.Unmarshaler interface.
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// decode unmarshals the "data" key of a GraphQL response into v, which should be passed by
+// reference. It behaves exactly like json.Unmarshal except for struct fields tagged as inline
+// fragments, which are only filled in if their type condition matches the __typename of the
+// object they were selected on and are left as their zero value otherwise.
+func decode(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || !hasFragments(rv.Type()) {
+		// Let encoding/json deal with (and report) anything that isn't special.
+		return json.Unmarshal(data, v)
+	}
+
+	return decodeValue(data, rv.Elem())
+}
+
+// decodeValue unmarshals raw into the addressable value v.
+func decodeValue(raw json.RawMessage, v reflect.Value) error {
+	if !hasFragments(v.Type()) {
+		return json.Unmarshal(raw, v.Addr().Interface())
+	}
+
+	null := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+
+	switch v.Kind() { //nolint:exhaustive // Why: hasFragments is only true for these kinds.
+	case reflect.Ptr:
+		if null {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(raw, v.Elem())
+	case reflect.Slice, reflect.Array:
+		if null {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+
+		if v.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(v.Type(), len(items), len(items)))
+		} else {
+			v.Set(reflect.Zero(v.Type()))
+		}
+
+		for i := 0; i < len(items) && i < v.Len(); i++ {
+			if err := decodeValue(items[i], v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		if null {
+			return nil
+		}
+		return decodeStruct(raw, v)
+	}
+
+	return json.Unmarshal(raw, v.Addr().Interface())
+}
+
+// decodeStruct unmarshals the JSON object raw into the addressable struct value v, only filling
+// in the inline fragments of v whose type condition matches the __typename of the object.
+func decodeStruct(raw json.RawMessage, v reflect.Value) error { //nolint:gocyclo
+	// Everything that encoding/json can deal with on its own is decoded first. This includes
+	// the fields of embedded inline fragments, since they get promoted into v.
+	if err := json.Unmarshal(raw, v.Addr().Interface()); err != nil {
+		return err
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return err
+	}
+
+	var typename string
+	if rawTypename, exists := object[typenameField]; exists {
+		if err := json.Unmarshal(rawTypename, &typename); err != nil {
+			return err
+		}
+	}
+
+	st := v.Type()
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+
+		// Unexported fields are skipped during marshaling as well.
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		fv := v.Field(i)
+
+		if f, err := parseTag(sf.Tag); err == nil && f.isFragment() {
+			if f.Decl.TypeCondition != typename {
+				fv.Set(reflect.Zero(sf.Type))
+				continue
+			}
+
+			// The fields of an inline fragment are part of the object it was selected on.
+			if err := decodeValue(raw, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !hasFragments(sf.Type) {
+			continue
+		}
+
+		name, promoted := jsonName(sf)
+		if promoted {
+			if err := decodeValue(raw, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if rawField, exists := lookupKey(object, name); exists {
+			if err := decodeValue(rawField, fv); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// jsonName returns the key that encoding/json decodes the given struct field from. If the field
+// is an embedded struct without a name in its json tag, its fields are promoted into the parent
+// object instead, which is denoted by the returned bool.
+func jsonName(sf reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name != "" {
+		return name, false
+	}
+
+	if sf.Anonymous && deref(sf.Type).Kind() == reflect.Struct {
+		return "", true
+	}
+
+	return sf.Name, false
+}
+
+// lookupKey finds key in the given JSON object, preferring an exact match but falling back to a
+// case-insensitive one, mimicking encoding/json.
+func lookupKey(object map[string]json.RawMessage, key string) (json.RawMessage, bool) {
+	if raw, exists := object[key]; exists {
+		return raw, true
+	}
+
+	for k, raw := range object {
+		if strings.EqualFold(k, key) {
+			return raw, true
+		}
+	}
+
+	return nil, false
+}
+
+// hasFragments reports whether or not the given type contains a struct field tagged as an inline
+// fragment anywhere within it. Types that implement json.Unmarshaler are left to decode
+// themselves and never report having fragments.
+func hasFragments(t reflect.Type) bool {
+	if cached, hit := fragmentCache.Load(t); hit {
+		return cached.(bool)
+	}
+
+	has := hasFragmentsSeen(t, make(map[reflect.Type]bool))
+	fragmentCache.Store(t, has)
+	return has
+}
+
+// hasFragmentsSeen is the recursive implementation of hasFragments, seen guards against
+// infinitely recursing through self-referential types.
+func hasFragmentsSeen(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	var has bool
+	switch t.Kind() { //nolint:exhaustive // Why: only these kinds can contain struct fields.
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		has = hasFragmentsSeen(t.Elem(), seen)
+	case reflect.Struct:
+		if reflect.PointerTo(t).Implements(unmarshalerType) {
+			break
+		}
+
+		for i := 0; i < t.NumField() && !has; i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+
+			if f, err := parseTag(sf.Tag); err == nil && f.isFragment() {
+				has = true
+				break
+			}
+			has = hasFragmentsSeen(sf.Type, seen)
+		}
+	}
+
+	return has
+}
@@ -0,0 +1,80 @@
+package goql
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestDecode tests the decode function.
+func TestDecode(t *testing.T) {
+	type User struct {
+		Name string `json:"name"`
+	}
+
+	type Team struct {
+		Size int `json:"size"`
+	}
+
+	type Node struct {
+		ID    string `json:"id"`
+		User  `goql:"... on User"`
+		Team  *Team `goql:"... on Team"`
+		Owner *struct {
+			Name string `json:"name"`
+		} `json:"owner"`
+	}
+
+	type Query struct {
+		Nodes []Node `json:"nodes"`
+		Node  *Node  `json:"node"`
+	}
+
+	tt := []struct {
+		Name           string
+		Input          string
+		ExpectedOutput Query
+	}{
+		{
+			Name:  "MatchesTypename",
+			Input: `{"nodes":[{"__typename":"User","id":"1","name":"Jane"},{"__typename":"Team","id":"2","size":3}]}`,
+			ExpectedOutput: Query{
+				Nodes: []Node{
+					{ID: "1", User: User{Name: "Jane"}},
+					{ID: "2", Team: &Team{Size: 3}},
+				},
+			},
+		},
+		{
+			Name:  "NoMatchingTypename",
+			Input: `{"node":{"__typename":"Robot","id":"3","name":"R2","size":2}}`,
+			ExpectedOutput: Query{
+				Node: &Node{ID: "3"},
+			},
+		},
+		{
+			Name:  "Null",
+			Input: `{"nodes":null,"node":null}`,
+			ExpectedOutput: Query{
+				Nodes: nil,
+				Node:  nil,
+			},
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			var actualOutput Query
+			if err := decode([]byte(test.Input), &actualOutput); err != nil {
+				t.Fatalf("error decoding data: %v", err)
+			}
+
+			if diff := cmp.Diff(test.ExpectedOutput, actualOutput); diff != "" {
+				t.Errorf("unexpected difference between expected and actual output:\n%s", diff)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
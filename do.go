@@ -4,13 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
 
 	"github.com/getoutreach/gobox/pkg/events"
 	"github.com/getoutreach/gobox/pkg/log"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // Valid operation types that the client can perform against the GraphQL server. These
@@ -35,41 +36,12 @@ type Operation struct {
 }
 
 // request is the type that contains the structure of a request that a GraphQL server expects.
+// The query is omitted when the hash of it is sent in its place, see persistedQuery.
 type request struct {
-	Query     string                 `json:"query"`
-	Variables map[string]interface{} `json:"variables"`
-}
-
-// Error is the type that contains the structure of an error returned from a GraphQL server. The
-// Extensions key is intentionally left as a json.RawMessage so that it can optionally be handled
-// and marshaled into whatever type necessary by the ErrorMapper passed to the client.
-type Error struct {
-	Message    string          `json:"message"`
-	Path       []string        `json:"path"`
-	Extensions json.RawMessage `json:"extensions"`
-}
-
-// Errors is a type alias for a slice of Error, which is what is returned in the response of a
-// request to a GraphQL server. More information is available on the Error type's documentation.
-type Errors []Error
-
-// Error is a value receiver function on the Errors type which implements the error interface for
-// its receiver. This allows the type to be returned as a normal error, but it can also be asserted
-// to it's original type if desired.
-func (e Errors) Error() string {
-	errs := make([]string, 0, len(e))
-	for i := range e {
-		errs = append(errs, e[i].Message)
-	}
-	return strings.Join(errs, ", ")
-}
-
-// response is the type that contains the structure of a response from a GraphQL server.
-type response struct {
-	// Data uses json.RawMessage to delay decoding of itself since we don't
-	// know the type of it at compile time.
-	Data   json.RawMessage `json:"data"`
-	Errors Errors          `json:"errors,omitempty"`
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    *extensions            `json:"extensions,omitempty"`
 }
 
 // doCustom takes a query as a string and performs a GraphQL operation. The response
@@ -78,32 +50,30 @@ type response struct {
 // is discarded.
 func (c *Client) doCustom(ctx context.Context, query string, variables map[string]interface{}, resp interface{},
 	headers http.Header) error {
-	var buf bytes.Buffer
-
-	// Create the request body using the constructed query or mutation.
-	if err := json.NewEncoder(&buf).Encode(request{ //nolint:gocritic
+	req := Request{
 		Query:     query,
 		Variables: variables,
-	}); err != nil {
-		return err
+		Header:    headers,
 	}
 
 	// Do the request and get the "data" key of the response back as a json.RawMessage. Errors
-	// returned in the response from GraphQL are handled inside of c.do.
-	data, err := c.do(ctx, &buf, headers)
-	if err != nil {
+	// returned in the response from GraphQL are handled inside of c.do, partial data may come
+	// back along with them.
+	data, err := c.do(ctx, &req, c.retryPolicy.idempotent(&req))
+	if err != nil && !hasData(data) {
 		return err
 	}
 
 	// Unmarshal the "data" key of the response into the desired struct that was passed in
-	// by reference, if it was not passed in an nil.
+	// by reference, if it was not passed in an nil. Failing to unmarshal partial data doesn't
+	// hide the errors that came with it.
 	if resp != nil {
-		if err := json.Unmarshal(data, resp); err != nil {
-			return err
+		if unmarshalErr := json.Unmarshal(data, resp); unmarshalErr != nil {
+			return errors.Join(err, unmarshalErr)
 		}
 	}
 
-	return nil
+	return err
 }
 
 // doStruct performs a request with a and retrieves a response from the GraphQL server
@@ -133,34 +103,257 @@ func (c *Client) doStruct(ctx context.Context, operationType int, operation *Ope
 		}
 	}
 
-	// Create the request body using the constructed query or mutation.
-	var buf bytes.Buffer
-	if err = json.NewEncoder(&buf).Encode(request{ //nolint:gocritic
-		Query:     queryStr,
-		Variables: operation.Variables,
-	}); err != nil {
-		return err
+	// The name of the operation was already validated while marshaling it.
+	name, _ := operationName(operation.OperationType) //nolint:errcheck
+
+	req := Request{
+		Query:         queryStr,
+		OperationName: name,
+		Variables:     operation.Variables,
+		Header:        headers,
 	}
 
 	// Do the request and get the "data" key of the response back as a json.RawMessage. Errors
-	// returned in the response from GraphQL are handled inside of c.do.
-	data, err := c.do(ctx, &buf, headers)
+	// returned in the response from GraphQL are handled inside of c.do, partial data may come
+	// back along with them.
+	data, err := c.do(ctx, &req, operationType == opQuery || c.retryPolicy.RetryMutations)
+	if err != nil && !hasData(data) {
+		return err
+	}
+
+	// Decode the "data" key of the response into the desired struct that was passed in
+	// by reference. Failing to decode partial data doesn't hide the errors that came with it.
+	if decodeErr := decode(data, operation.OperationType); decodeErr != nil {
+		return errors.Join(err, decodeErr)
+	}
+
+	return err
+}
+
+// doComposed performs a single request that is composed of several operations of the same type
+// and decodes the data of each of them into its own OperationType.
+func (c *Client) doComposed(ctx context.Context, operationType int, operations []*Operation, headers http.Header) error {
+	// An operation without any root fields isn't valid GraphQL, so there's nothing to send.
+	if len(operations) == 0 {
+		return nil
+	}
+
+	wrapper := "query"
+	if operationType == opMutation {
+		wrapper = "mutation"
+	}
+
+	o := optStruct{tp: parseTag}
+	for _, opt := range c.marshalOpts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+
+	operation, err := compose(operations, wrapper, o.tp)
 	if err != nil {
 		return err
 	}
 
-	// Unmarshal the "data" key of the response into the desired struct that was passed in
-	// by reference.
-	return json.Unmarshal(data, operation.OperationType)
+	// The composed operation is formatted the same way as any other operation of the client.
+	if o.format != nil {
+		if operation.Query, err = o.format(operation.Query); err != nil {
+			return err
+		}
+	}
+
+	req := Request{
+		Query:     operation.Query,
+		Variables: operation.Variables,
+		Header:    headers,
+	}
+
+	// Do the request and get the "data" key of the response back as a json.RawMessage. Errors
+	// returned in the response from GraphQL are handled inside of c.do, partial data may come
+	// back along with them.
+	data, err := c.do(ctx, &req, operationType == opQuery || c.retryPolicy.RetryMutations)
+	if err != nil && !hasData(data) {
+		return err
+	}
+
+	// Errors decoding partial data are returned along with the errors returned in the response,
+	// rather than in place of them.
+	parts, decompErr := decomposed(data, len(operations))
+	if decompErr != nil {
+		return errors.Join(err, decompErr)
+	}
+
+	for i := range operations {
+		if decodeErr := decode(parts[i], operations[i].OperationType); decodeErr != nil {
+			return errors.Join(err, decodeErr)
+		}
+	}
+
+	return err
 }
 
-// do performs a GraphQL operation given a request body and headers. The "data" key of the
-// GraphQL response is returned as a json.RawMessage for the caller to unmarshal. The errors
-// returned in the response, if any, are dealt with in this function and returned as an
-// error type, using c.errorMapper.
-func (c *Client) do(ctx context.Context, body io.Reader, headers http.Header) (json.RawMessage, error) { //nolint:funlen
-	// Create a request to query the GraphQL server located at the configured URL.
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, body)
+// hasData denotes whether or not the "data" key of a GraphQL response holds any data.
+func hasData(data json.RawMessage) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && !bytes.Equal(data, []byte("null"))
+}
+
+// do performs a GraphQL request through the middleware of the client, retrying it according to
+// the retry policy of the client if it is idempotent. The "data" key of the GraphQL response
+// is returned as a json.RawMessage for the caller to unmarshal. The errors returned in the
+// response, if any, are dealt with in this function and returned as an error type, using
+// c.errorMapper. If the client allows partial data, the "data" key is returned along with them.
+func (c *Client) do(ctx context.Context, req *Request, idempotent bool) (json.RawMessage, error) {
+	handler := func(ctx context.Context, req *Request) (*Response, error) {
+		return c.roundTrip(ctx, req, idempotent)
+	}
+
+	// The first middleware is the outermost one, so wrap the handler starting from the last.
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		handler = c.middleware[i](handler)
+	}
+
+	// Telemetry wraps all of the middleware to account for the time spent in it.
+	handler = c.telemetry.middleware(handler)
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp == nil {
+		return nil, errNoResponse
+	}
+
+	// If an error occurred, return it immediately, along with whatever data came with it if
+	// partial data is allowed.
+	if len(resp.Errors) > 0 {
+		errs := resp.Errors.withStatusCode(resp.StatusCode)
+		if c.allowPartialData {
+			return resp.Data, c.errorMapper(resp.StatusCode, errs)
+		}
+		return nil, c.errorMapper(resp.StatusCode, errs)
+	}
+
+	return resp.Data, nil
+}
+
+// roundTrip sends a GraphQL request to the GraphQL server configured in the receiver and returns
+// its response. If the client uses persisted queries, the hash of the query is sent in place of
+// it first, and the query itself is only sent if the GraphQL server doesn't know the hash yet.
+func (c *Client) roundTrip(ctx context.Context, req *Request, idempotent bool) (*Response, error) {
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	body := request{
+		Query:         req.Query,
+		OperationName: req.OperationName,
+		Variables:     req.Variables,
+	}
+
+	if c.persistedQueries && !c.persistedQueriesUnsupported.Load() {
+		body.Query = ""
+		body.Extensions = &extensions{
+			PersistedQuery: &persistedQuery{
+				Version:    persistedQueryVersion,
+				SHA256Hash: documentHash(req.Query),
+			},
+		}
+
+		resp, err := c.attempt(ctx, req, &body, idempotent)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case persistedQueryNotFound(resp.Errors):
+			// Send the query along with its hash for the GraphQL server to store it.
+			body.Query = req.Query
+		case persistedQueryNotSupported(resp.Errors):
+			// Stop sending hashes to a GraphQL server that doesn't support them.
+			c.persistedQueriesUnsupported.Store(true)
+			body.Query, body.Extensions = req.Query, nil
+		default:
+			return resp, nil
+		}
+	}
+
+	return c.attempt(ctx, req, &body, idempotent)
+}
+
+// attempt sends the given body of req to the GraphQL server configured in the receiver, retrying
+// it according to the retry policy of the client if it is idempotent, and returns its response.
+func (c *Client) attempt(ctx context.Context, req *Request, body *request, idempotent bool) (*Response, error) {
+	attrs := metric.WithAttributes(c.telemetry.attributes(req)...)
+
+	// Queries are sent as GET requests if the client is configured to, with the body encoded
+	// into the URL. Everything else is sent as a POST request.
+	target, get, err := c.getURL(req, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if get {
+		// The request of a GET request is its query string, there's no body.
+		_, rawQuery, _ := strings.Cut(target, "?")
+		c.telemetry.requestSize.Record(ctx, int64(len(rawQuery)), attrs)
+
+		return c.withRetries(ctx, idempotent, func() (*Response, error) {
+			resp, err := c.send(ctx, http.MethodGet, target, http.NoBody, req.Header, false)
+			if resp != nil {
+				c.telemetry.responseSize.Record(ctx, resp.size, attrs)
+			}
+			return resp, err
+		})
+	}
+
+	// The body is sent again on every attempt, so it needs to be held onto.
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	c.telemetry.requestSize.Record(ctx, int64(len(b)), attrs)
+
+	// Batches are sent, and retried, as a whole.
+	if c.batcher != nil {
+		return c.batcher.do(ctx, b, req.Header, idempotent, attrs)
+	}
+
+	return c.withRetries(ctx, idempotent, func() (*Response, error) {
+		resp, err := c.send(ctx, http.MethodPost, c.url, bytes.NewReader(b), req.Header, false)
+		if resp != nil {
+			c.telemetry.responseSize.Record(ctx, resp.size, attrs)
+		}
+		return resp, err
+	})
+}
+
+// withRetries calls send until it returns a response or an error that isn't retried according
+// to the retry policy of the client, waiting in between attempts.
+func (c *Client) withRetries(ctx context.Context, idempotent bool, send func() (*Response, error)) (*Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := send()
+
+		wait, retry := c.retryPolicy.retry(ctx, attempt, idempotent, resp, err)
+		if !retry {
+			return resp, err
+		}
+
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send performs a single attempt of a GraphQL operation given the method and URL of the request,
+// its body, and headers. If batched is true, the body is a batch of operations and the responses
+// to them are returned in the batch of the returned response. If the response received is not a
+// GraphQL response, an *HTTPError is returned.
+func (c *Client) send(ctx context.Context, method, target string, body io.Reader, headers http.Header, //nolint:funlen
+	batched bool) (*Response, error) {
+	// Create a request to query the GraphQL server located at the given URL.
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
 	if err != nil {
 		return nil, err
 	}
@@ -176,6 +369,8 @@ func (c *Client) do(ctx context.Context, body io.Reader, headers http.Header) (j
 	req.Header = headers
 
 	// The Content-Type of this request will always be application/json as per the GraphQL specification.
+	// GET requests have no body, but the header is still set since GraphQL servers that protect
+	// against CSRF reject GET requests that could've been sent by a plain HTML form.
 	req.Header.Set("Content-Type", applicationJSON)
 
 	// We don't want this header to be set because then we won't get the luxury of the transport automatically
@@ -198,17 +393,39 @@ func (c *Client) do(ctx context.Context, body io.Reader, headers http.Header) (j
 		}
 	}()
 
-	var gqlResp response
+	gqlResp := Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+	}
 
 	// Create two copies from the response buffer, one to decode and one to fall back on if the decoding
 	// fails for any reason.
 	var fallbackCopy bytes.Buffer
 	decoderCopy := io.TeeReader(resp.Body, &fallbackCopy)
 
-	// Attempt to decode the response from the GraphQL server.
-	if err := json.NewDecoder(decoderCopy).Decode(&gqlResp); err != nil {
-		// If the decode attempt failed, dump the body and return.
-		b, err := io.ReadAll(&fallbackCopy)
+	// Attempt to decode the response from the GraphQL server, batches of operations are responded
+	// to with an array of responses. Responses that aren't GraphQL responses, e.g. error pages of
+	// proxies in between, either fail to decode or, if they happen to be JSON, carry neither data
+	// nor errors. The size of each response of a batch is that of its own part of the body.
+	if batched {
+		var parts []json.RawMessage
+		if err = json.NewDecoder(decoderCopy).Decode(&parts); err == nil {
+			gqlResp.batch = make([]Response, len(parts))
+			for i := range parts {
+				if err = json.Unmarshal(parts[i], &gqlResp.batch[i]); err != nil {
+					break
+				}
+				gqlResp.batch[i].size = int64(len(parts[i]))
+			}
+		}
+	} else {
+		err = json.NewDecoder(decoderCopy).Decode(&gqlResp)
+	}
+
+	if err != nil || (resp.StatusCode >= http.StatusMultipleChoices && !batched && !hasData(gqlResp.Data) && len(gqlResp.Errors) == 0) {
+		// Return what was received, including whatever is left of the body that the decoder
+		// didn't get to, up to a limit.
+		b, err := io.ReadAll(io.LimitReader(io.MultiReader(&fallbackCopy, resp.Body), maxHTTPErrorBody+1))
 		if err != nil {
 			log.Error(ctx, "read non-200 status response body from graphql server",
 				events.Err(err), log.F{
@@ -216,14 +433,14 @@ func (c *Client) do(ctx context.Context, body io.Reader, headers http.Header) (j
 				})
 		}
 
-		return nil, fmt.Errorf("unknown response format with status %d received from graphql server: %s",
-			resp.StatusCode, b)
+		return nil, newHTTPError(resp, b)
 	}
+	gqlResp.size = int64(fallbackCopy.Len())
 
-	// If an error occurred, return it immediately.
-	if len(gqlResp.Errors) > 0 {
-		return nil, c.errorMapper(resp.StatusCode, gqlResp.Errors)
+	for i := range gqlResp.batch {
+		gqlResp.batch[i].StatusCode = resp.StatusCode
+		gqlResp.batch[i].Header = resp.Header
 	}
 
-	return gqlResp.Data, nil
+	return &gqlResp, nil
 }
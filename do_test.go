@@ -1,11 +1,14 @@
 package goql
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/getoutreach/goql/graphql_test"
 )
@@ -220,30 +223,145 @@ func TestDoStruct(t *testing.T) {
 	}
 }
 
+// TestDoTypedErrors tests that the errors returned from the GraphQL server are classified using
+// the status code of the response they came with.
+func TestDoTypedErrors(t *testing.T) {
+	t.Parallel()
+
+	ts := graphql_test.NewServer(t, true)
+	t.Cleanup(ts.Close)
+
+	ts.RegisterError(graphql_test.OperationError{
+		Identifier: "rateLimited",
+		Status:     http.StatusTooManyRequests,
+		Error:      errors.New("slow down"),
+	})
+
+	client := NewClient(ts.URL, DefaultClientOptions)
+
+	err := client.CustomOperation(context.Background(), "error { rateLimited }", nil, nil)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected error to be ErrRateLimited, got %v", err)
+	}
+
+	if errors.Is(err, ErrNotFound) {
+		t.Errorf("expected error not to be ErrNotFound, got %v", err)
+	}
+}
+
+// TestDoHTTPError tests that responses which aren't GraphQL responses are returned as an
+// *HTTPError.
+func TestDoHTTPError(t *testing.T) {
+	tt := []struct {
+		Name               string
+		Status             int
+		Header             http.Header
+		Body               string
+		ExpectedBody       string
+		ExpectedTruncated  bool
+		ExpectedRetryAfter time.Duration
+		ExpectedClass      error
+	}{
+		{
+			Name:          "BadGatewayPage",
+			Status:        http.StatusBadGateway,
+			Header:        http.Header{"Content-Type": []string{"text/html"}},
+			Body:          "<html>502 Bad Gateway</html>",
+			ExpectedBody:  "<html>502 Bad Gateway</html>",
+			ExpectedClass: nil,
+		},
+		{
+			Name:               "RateLimitedWithRetryAfter",
+			Status:             http.StatusTooManyRequests,
+			Header:             http.Header{"Content-Type": []string{"text/plain"}, "Retry-After": []string{"30"}},
+			Body:               strings.Repeat("a", maxHTTPErrorBody+100),
+			ExpectedBody:       strings.Repeat("a", maxHTTPErrorBody),
+			ExpectedTruncated:  true,
+			ExpectedRetryAfter: 30 * time.Second,
+			ExpectedClass:      ErrRateLimited,
+		},
+		{
+			Name:          "JSONWithoutDataOrErrors",
+			Status:        http.StatusServiceUnavailable,
+			Header:        http.Header{"Content-Type": []string{applicationJSON}},
+			Body:          `{"message":"upstream unavailable"}`,
+			ExpectedBody:  `{"message":"upstream unavailable"}`,
+			ExpectedClass: nil,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				for k, v := range test.Header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(test.Status)
+				io.WriteString(w, test.Body) //nolint:errcheck
+			}))
+			t.Cleanup(ts.Close)
+
+			client := NewClient(ts.URL, DefaultClientOptions)
+
+			err := client.CustomOperation(context.Background(), "query { foo }", nil, nil)
+
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("expected error of type *HTTPError, got %v", err)
+			}
+
+			if e, a := test.Status, httpErr.StatusCode; e != a {
+				t.Errorf("expected status code to be %d, got %d", e, a)
+			}
+
+			if e, a := test.Header.Get("Content-Type"), httpErr.ContentType; e != a {
+				t.Errorf("expected content type to be %s, got %s", e, a)
+			}
+
+			if e, a := test.ExpectedBody, string(httpErr.Body); e != a {
+				t.Errorf("expected body to be %s, got %s", e, a)
+			}
+
+			if e, a := test.ExpectedTruncated, httpErr.Truncated; e != a {
+				t.Errorf("expected truncated to be %t, got %t", e, a)
+			}
+
+			retryAfter, ok := httpErr.RetryAfter()
+			if e, a := test.ExpectedRetryAfter, retryAfter; e != a || ok != (e != 0) {
+				t.Errorf("expected retry after to be %s, got %s (%t)", e, a, ok)
+			}
+
+			if test.ExpectedClass != nil && !errors.Is(err, test.ExpectedClass) {
+				t.Errorf("expected error to be %v, got %v", test.ExpectedClass, err)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
+
 func TestDoHeadersOverride(t *testing.T) {
 	ts := graphql_test.NewServer(t, true)
 	t.Cleanup(ts.Close)
-	var buf bytes.Buffer
-	query := "query"
-	if err := json.NewEncoder(&buf).Encode(request{ //nolint:gocritic
-		Query:     query,
+	req := Request{
+		Query:     "query",
 		Variables: nil,
-	}); err != nil {
-		t.Fatalf("error building buffer: %v", err)
 	}
 	headers := http.Header{}
+	req.Header = headers
 	client := NewClient(ts.URL, DefaultClientOptions)
-	client.do(context.Background(), &buf, headers)
+	client.do(context.Background(), &req, false)
 	if headers.Get("Accept") != applicationJSON {
 		t.Fatal("Unexpected header Accept")
 	}
 	headers.Set("Accept", "some header")
-	client.do(context.Background(), &buf, headers)
+	client.do(context.Background(), &req, false)
 	if headers.Get("Accept") != applicationJSON {
 		t.Fatal("Unexpected header Accept value")
 	}
 	headers.Set("Accept", applicationJSON)
-	client.do(context.Background(), &buf, headers)
+	client.do(context.Background(), &req, false)
 	if headers.Get("Accept") != applicationJSON {
 		t.Fatal("Unexpected header Accept value")
 	}
@@ -0,0 +1,361 @@
+package goql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Classes of errors returned from GraphQL servers. An Error is of a class if the "code" key of
+// its extensions or, failing that, the status code of the response it came with denotes so.
+// Errors and Error work with errors.Is, e.g. errors.Is(err, goql.ErrNotFound) reports whether
+// or not any of the errors returned by the default ErrorMapper is of the not found class.
+var (
+	// ErrNotFound is the class of errors with the NOT_FOUND code or a 404 Not Found status.
+	ErrNotFound = errors.New("not found")
+
+	// ErrUnauthenticated is the class of errors with the UNAUTHENTICATED code or a 401
+	// Unauthorized status.
+	ErrUnauthenticated = errors.New("unauthenticated")
+
+	// ErrValidation is the class of errors with the GRAPHQL_VALIDATION_FAILED,
+	// GRAPHQL_PARSE_FAILED, BAD_USER_INPUT, or VALIDATION_FAILED code or a 400 Bad Request or
+	// 422 Unprocessable Entity status.
+	ErrValidation = errors.New("validation failed")
+
+	// ErrRateLimited is the class of errors with the RATE_LIMITED or TOO_MANY_REQUESTS code or
+	// a 429 Too Many Requests status.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// codeClasses maps the codes found in the extensions of errors to their class.
+var codeClasses = map[string]error{
+	"NOT_FOUND":                 ErrNotFound,
+	"UNAUTHENTICATED":           ErrUnauthenticated,
+	"GRAPHQL_VALIDATION_FAILED": ErrValidation,
+	"GRAPHQL_PARSE_FAILED":      ErrValidation,
+	"BAD_USER_INPUT":            ErrValidation,
+	"VALIDATION_FAILED":         ErrValidation,
+	"RATE_LIMITED":              ErrRateLimited,
+	"TOO_MANY_REQUESTS":         ErrRateLimited,
+}
+
+// statusClasses maps the status codes of responses to the class of the errors they came with.
+var statusClasses = map[int]error{
+	http.StatusNotFound:            ErrNotFound,
+	http.StatusUnauthorized:        ErrUnauthenticated,
+	http.StatusBadRequest:          ErrValidation,
+	http.StatusUnprocessableEntity: ErrValidation,
+	http.StatusTooManyRequests:     ErrRateLimited,
+}
+
+// maxHTTPErrorBody is the maximum number of bytes of the body of a response that an HTTPError
+// holds onto.
+const maxHTTPErrorBody = 4096
+
+// HTTPError is the error returned when the response received from the GraphQL server is not a
+// GraphQL response, e.g. when a proxy in between responds with an error page. It works with
+// errors.Is and the classes of errors defined by this package based on its status code, e.g. a
+// 429 Too Many Requests response is ErrRateLimited.
+type HTTPError struct {
+	// StatusCode is the status code of the response.
+	StatusCode int
+
+	// Header holds onto the headers of the response.
+	Header http.Header
+
+	// ContentType is the Content-Type of the response.
+	ContentType string
+
+	// Body is the body of the response, truncated to its first 4096 bytes.
+	Body []byte
+
+	// Truncated denotes whether or not Body was truncated.
+	Truncated bool
+}
+
+// newHTTPError returns an HTTPError for the given response whose body, read up to one byte past
+// the limit to be able to tell whether or not it was truncated, is b.
+func newHTTPError(resp *http.Response, b []byte) *HTTPError {
+	e := HTTPError{
+		StatusCode:  resp.StatusCode,
+		Header:      resp.Header,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        b,
+	}
+
+	if len(e.Body) > maxHTTPErrorBody {
+		e.Body, e.Truncated = e.Body[:maxHTTPErrorBody], true
+	}
+
+	return &e
+}
+
+// Error implements the error interface for the HTTPError type.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unknown response format with status %d received from graphql server: %s", e.StatusCode, e.Body)
+}
+
+// Is reports whether or not the status code of the response denotes that the error is of the
+// class target. This makes errors.Is work with it.
+func (e *HTTPError) Is(target error) bool {
+	class := statusClasses[e.StatusCode]
+	return class != nil && class == target
+}
+
+// RetryAfter returns how long the server asked to wait before retrying through the Retry-After
+// header of the response, which is either a number of seconds or an HTTP date. The returned
+// bool is false if the header is missing or invalid.
+func (e *HTTPError) RetryAfter() (time.Duration, bool) {
+	return retryAfter(e.Header, time.Now())
+}
+
+// retryAfter parses the Retry-After header in h relative to now.
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	value := strings.TrimSpace(h.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// Error is the type that contains the structure of an error returned from a GraphQL server. The
+// Extensions key is intentionally left as a json.RawMessage so that it can optionally be handled
+// and marshaled into whatever type necessary by the ErrorMapper passed to the client, see
+// DecodeExtensions.
+type Error struct {
+	Message    string          `json:"message"`
+	Locations  []Location      `json:"locations,omitempty"`
+	Path       Path            `json:"path"`
+	Extensions json.RawMessage `json:"extensions"`
+
+	// statusCode is the status code of the response that the error came with, it is set by the
+	// Client.
+	statusCode int
+}
+
+// Error implements the error interface for a single Error, returning its message.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is reports whether or not the error is of the class target, which is one of ErrNotFound,
+// ErrUnauthenticated, ErrValidation, or ErrRateLimited. This makes errors.Is work with it.
+func (e *Error) Is(target error) bool {
+	class := e.class()
+	return class != nil && class == target
+}
+
+// class returns the class of the error, derived from the code in its extensions or, failing
+// that, from the status code of the response it came with. nil is returned if neither denotes
+// a known class.
+func (e *Error) class() error {
+	if class, exists := codeClasses[e.Code()]; exists {
+		return class
+	}
+	return statusClasses[e.statusCode]
+}
+
+// DecodeExtensions unmarshals the extensions of the error into v, which should be passed by
+// reference. It's a no-op if the error has no extensions.
+func (e *Error) DecodeExtensions(v interface{}) error {
+	if len(e.Extensions) == 0 {
+		return nil
+	}
+	return json.Unmarshal(e.Extensions, v)
+}
+
+// Code returns the "code" key of the extensions of the error, which is where GraphQL servers
+// conventionally classify their errors, e.g. "UNAUTHENTICATED". An empty string is returned if
+// there is none.
+func (e *Error) Code() string {
+	var extensions struct {
+		Code string `json:"code"`
+	}
+
+	if err := e.DecodeExtensions(&extensions); err != nil {
+		return ""
+	}
+	return extensions.Code
+}
+
+// Location is a location within the GraphQL operation that an Error refers to.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// PathSegment is a single segment of the Path of an Error, which is either the key of a field
+// or the index of an item within a list.
+type PathSegment struct {
+	// Field is the key of the field that the segment refers to. It is empty if the segment is
+	// the index of an item within a list.
+	Field string
+
+	// Index is the index of the item within a list that the segment refers to, it is only
+	// meaningful if Field is empty.
+	Index int
+}
+
+// IsIndex denotes whether or not the segment is the index of an item within a list.
+func (s PathSegment) IsIndex() bool {
+	return s.Field == ""
+}
+
+// String returns the field key or the index of the segment.
+func (s PathSegment) String() string {
+	if s.IsIndex() {
+		return strconv.Itoa(s.Index)
+	}
+	return s.Field
+}
+
+// MarshalJSON implements the json.Marshaler interface, marshaling the segment into either a
+// string or a number.
+func (s PathSegment) MarshalJSON() ([]byte, error) {
+	if s.IsIndex() {
+		return json.Marshal(s.Index)
+	}
+	return json.Marshal(s.Field)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, unmarshaling the segment from either
+// a string or a number.
+func (s *PathSegment) UnmarshalJSON(b []byte) error {
+	var segment interface{}
+	if err := json.Unmarshal(b, &segment); err != nil {
+		return err
+	}
+
+	switch segment := segment.(type) {
+	case string:
+		*s = PathSegment{Field: segment}
+	case float64:
+		if segment != float64(int(segment)) {
+			return fmt.Errorf("path segment must be a string or an integer, got %s", b)
+		}
+		*s = PathSegment{Index: int(segment)}
+	default:
+		return fmt.Errorf("path segment must be a string or an integer, got %s", b)
+	}
+
+	return nil
+}
+
+// Path is the path of the field within the response that an Error refers to, e.g.
+// ["users", 3, "email"].
+type Path []PathSegment
+
+// String returns the path with its segments joined by dots, e.g. users.3.email.
+func (p Path) String() string {
+	segments := make([]string, 0, len(p))
+	for i := range p {
+		segments = append(segments, p[i].String())
+	}
+	return strings.Join(segments, ".")
+}
+
+// HasPrefix denotes whether or not the path starts with the given segments, each of which is
+// either a string that matches the key of a field or an int that matches the index of an item
+// within a list. Segments of any other type never match.
+func (p Path) HasPrefix(prefix ...interface{}) bool {
+	if len(prefix) > len(p) {
+		return false
+	}
+
+	for i := range prefix {
+		switch segment := prefix[i].(type) {
+		case string:
+			if p[i].IsIndex() || p[i].Field != segment {
+				return false
+			}
+		case int:
+			if !p[i].IsIndex() || p[i].Index != segment {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// Errors is a type alias for a slice of Error, which is what is returned in the response of a
+// request to a GraphQL server. More information is available on the Error type's documentation.
+type Errors []Error
+
+// Error is a value receiver function on the Errors type which implements the error interface for
+// its receiver. This allows the type to be returned as a normal error, but it can also be asserted
+// to it's original type if desired.
+func (e Errors) Error() string {
+	errs := make([]string, 0, len(e))
+	for i := range e {
+		errs = append(errs, e[i].Message)
+	}
+	return strings.Join(errs, ", ")
+}
+
+// Unwrap returns each of the errors as a *Error, which makes errors.Is and errors.As look
+// through all of them, e.g. errors.Is(errs, ErrNotFound) reports whether or not any of them is
+// of the not found class.
+func (e Errors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for i := range e {
+		errs = append(errs, &e[i])
+	}
+	return errs
+}
+
+// withStatusCode sets the status code of the response that the errors came with on each of them.
+func (e Errors) withStatusCode(statusCode int) Errors {
+	for i := range e {
+		e[i].statusCode = statusCode
+	}
+	return e
+}
+
+// WithPathPrefix returns the errors whose path starts with the given segments, see Path.HasPrefix
+// for more information on how they're matched. This is useful to find the errors that relate to a
+// certain part of a partial response, e.g. errs.WithPathPrefix("users", 3).
+func (e Errors) WithPathPrefix(prefix ...interface{}) Errors {
+	var errs Errors
+	for i := range e {
+		if e[i].Path.HasPrefix(prefix...) {
+			errs = append(errs, e[i])
+		}
+	}
+	return errs
+}
+
+// WithCode returns the errors whose extensions have the given code, see Error.Code for more
+// information.
+func (e Errors) WithCode(code string) Errors {
+	var errs Errors
+	for i := range e {
+		if e[i].Code() == code {
+			errs = append(errs, e[i])
+		}
+	}
+	return errs
+}
@@ -0,0 +1,285 @@
+package goql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestErrorsUnmarshal tests that errors with paths of mixed segments and locations are
+// unmarshaled from, and marshaled back into, their JSON representation.
+func TestErrorsUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	input := `[{"message":"email is unavailable","locations":[{"line":3,"column":5}],"path":["users",3,"email"],` +
+		`"extensions":{"code":"FORBIDDEN"}}]`
+
+	var errs Errors
+	if err := json.Unmarshal([]byte(input), &errs); err != nil {
+		t.Fatalf("error unmarshaling errors: %v", err)
+	}
+
+	expected := Errors{
+		{
+			Message:    "email is unavailable",
+			Locations:  []Location{{Line: 3, Column: 5}},
+			Path:       Path{{Field: "users"}, {Index: 3}, {Field: "email"}},
+			Extensions: json.RawMessage(`{"code":"FORBIDDEN"}`),
+		},
+	}
+
+	if diff := cmp.Diff(expected, errs, cmp.AllowUnexported(Error{})); diff != "" {
+		t.Errorf("unexpected errors (-want +got):\n%s", diff)
+	}
+
+	if e, a := "users.3.email", errs[0].Path.String(); e != a {
+		t.Errorf("expected path to be %s, got %s", e, a)
+	}
+
+	if e, a := "FORBIDDEN", errs[0].Code(); e != a {
+		t.Errorf("expected code to be %s, got %s", e, a)
+	}
+
+	b, err := json.Marshal(errs)
+	if err != nil {
+		t.Fatalf("error marshaling errors: %v", err)
+	}
+
+	if e, a := input, string(b); e != a {
+		t.Errorf("expected marshaled errors to be %s, got %s", e, a)
+	}
+
+	if err := json.Unmarshal([]byte(`[{"message":"foo","path":["users",1.5]}]`), &errs); err == nil {
+		t.Error("expected error unmarshaling path with a fractional index, got nil")
+	}
+}
+
+// TestErrorsFilter tests the WithPathPrefix and WithCode receiver functions on the Errors type.
+func TestErrorsFilter(t *testing.T) {
+	errs := Errors{
+		{
+			Message:    "first",
+			Path:       Path{{Field: "users"}, {Index: 0}, {Field: "email"}},
+			Extensions: json.RawMessage(`{"code":"FORBIDDEN"}`),
+		},
+		{
+			Message:    "second",
+			Path:       Path{{Field: "users"}, {Index: 3}, {Field: "email"}},
+			Extensions: json.RawMessage(`{"code":"NOT_FOUND"}`),
+		},
+		{
+			Message: "third",
+			Path:    Path{{Field: "organization"}},
+		},
+	}
+
+	tt := []struct {
+		Name           string
+		Filter         func(Errors) Errors
+		ExpectedOutput string
+	}{
+		{
+			Name:           "PathPrefixField",
+			Filter:         func(errs Errors) Errors { return errs.WithPathPrefix("users") },
+			ExpectedOutput: "first, second",
+		},
+		{
+			Name:           "PathPrefixIndex",
+			Filter:         func(errs Errors) Errors { return errs.WithPathPrefix("users", 3) },
+			ExpectedOutput: "second",
+		},
+		{
+			Name:           "PathPrefixMismatchedKind",
+			Filter:         func(errs Errors) Errors { return errs.WithPathPrefix("users", "3") },
+			ExpectedOutput: "",
+		},
+		{
+			Name:           "PathPrefixTooLong",
+			Filter:         func(errs Errors) Errors { return errs.WithPathPrefix("organization", "name") },
+			ExpectedOutput: "",
+		},
+		{
+			Name:           "Code",
+			Filter:         func(errs Errors) Errors { return errs.WithCode("NOT_FOUND") },
+			ExpectedOutput: "second",
+		},
+		{
+			Name:           "NoCode",
+			Filter:         func(errs Errors) Errors { return errs.WithCode("") },
+			ExpectedOutput: "third",
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			if e, a := test.ExpectedOutput, test.Filter(errs).Error(); e != a {
+				t.Errorf("expected filtered errors to be \"%s\", got \"%s\"", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
+
+// TestErrorsIs tests that the classes of errors are derived from their codes and status codes and
+// work with errors.Is and errors.As.
+func TestErrorsIs(t *testing.T) {
+	tt := []struct {
+		Name          string
+		Input         Error
+		ExpectedClass error // If ExpectedClass == nil, it implies the error has no class.
+	}{
+		{
+			Name:          "Code",
+			Input:         Error{Extensions: json.RawMessage(`{"code":"UNAUTHENTICATED"}`), statusCode: http.StatusOK},
+			ExpectedClass: ErrUnauthenticated,
+		},
+		{
+			Name:          "ValidationCode",
+			Input:         Error{Extensions: json.RawMessage(`{"code":"GRAPHQL_VALIDATION_FAILED"}`)},
+			ExpectedClass: ErrValidation,
+		},
+		{
+			Name:          "StatusCode",
+			Input:         Error{statusCode: http.StatusTooManyRequests},
+			ExpectedClass: ErrRateLimited,
+		},
+		{
+			Name:          "CodeOverridesStatusCode",
+			Input:         Error{Extensions: json.RawMessage(`{"code":"NOT_FOUND"}`), statusCode: http.StatusBadRequest},
+			ExpectedClass: ErrNotFound,
+		},
+		{
+			Name:          "UnknownCodeFallsBackToStatusCode",
+			Input:         Error{Extensions: json.RawMessage(`{"code":"SOMETHING_ELSE"}`), statusCode: http.StatusNotFound},
+			ExpectedClass: ErrNotFound,
+		},
+		{
+			Name:          "NoClass",
+			Input:         Error{Extensions: json.RawMessage(`{"code":"INTERNAL_SERVER_ERROR"}`), statusCode: http.StatusOK},
+			ExpectedClass: nil,
+		},
+	}
+
+	classes := []error{ErrNotFound, ErrUnauthenticated, ErrValidation, ErrRateLimited}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			// Wrap the error the way callers commonly do to make sure the classes are found
+			// regardless.
+			err := fmt.Errorf("do operation: %w", Errors{{Message: "foo"}, test.Input})
+
+			for _, class := range classes {
+				if e, a := class == test.ExpectedClass, errors.Is(err, class); e != a {
+					t.Errorf("expected errors.Is(err, %q) to be %t, got %t", class, e, a)
+				}
+			}
+
+			var gqlErr *Error
+			if !errors.As(err, &gqlErr) {
+				t.Fatalf("expected error to be assignable to *Error, got %v", err)
+			}
+
+			if e, a := "foo", gqlErr.Message; e != a {
+				t.Errorf("expected first error to be \"%s\", got \"%s\"", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
+
+// TestErrorDecodeExtensions tests the DecodeExtensions receiver function on the Error type.
+func TestErrorDecodeExtensions(t *testing.T) {
+	t.Parallel()
+
+	e := Error{
+		Extensions: json.RawMessage(`{"code":"RATE_LIMITED","retryAfter":30}`),
+	}
+
+	var extensions struct {
+		Code       string `json:"code"`
+		RetryAfter int    `json:"retryAfter"`
+	}
+
+	if err := e.DecodeExtensions(&extensions); err != nil {
+		t.Fatalf("error decoding extensions: %v", err)
+	}
+
+	if e, a := 30, extensions.RetryAfter; e != a {
+		t.Errorf("expected retryAfter to be %d, got %d", e, a)
+	}
+
+	if err := (&Error{}).DecodeExtensions(&extensions); err != nil {
+		t.Errorf("expected no error decoding missing extensions, got %v", err)
+	}
+}
+
+// TestRetryAfter tests the retryAfter function.
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		Name           string
+		Input          string
+		ExpectedOutput time.Duration
+		ExpectedOK     bool
+	}{
+		{
+			Name:           "Seconds",
+			Input:          "120",
+			ExpectedOutput: 2 * time.Minute,
+			ExpectedOK:     true,
+		},
+		{
+			Name:           "Date",
+			Input:          now.Add(90 * time.Second).Format(http.TimeFormat),
+			ExpectedOutput: 90 * time.Second,
+			ExpectedOK:     true,
+		},
+		{
+			Name:           "PastDate",
+			Input:          now.Add(-time.Hour).Format(http.TimeFormat),
+			ExpectedOutput: 0,
+			ExpectedOK:     true,
+		},
+		{
+			Name:       "Missing",
+			Input:      "",
+			ExpectedOK: false,
+		},
+		{
+			Name:       "Invalid",
+			Input:      "soon",
+			ExpectedOK: false,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			h := http.Header{}
+			if test.Input != "" {
+				h.Set("Retry-After", test.Input)
+			}
+
+			actualOutput, ok := retryAfter(h, now)
+			if e, a := test.ExpectedOK, ok; e != a {
+				t.Fatalf("expected ok to be %t, got %t", e, a)
+			}
+
+			if e, a := test.ExpectedOutput, actualOutput; e != a {
+				t.Errorf("expected output to be %s, got %s", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
@@ -0,0 +1,91 @@
+package goql
+
+import (
+	"strings"
+
+	"github.com/getoutreach/goql/schema"
+)
+
+// prettyPrint returns the document doc as written by schema.(*QueryDocument).WriteGraphQL,
+// with every selection indented by two spaces per level of nesting and the definitions of
+// named fragments separated by blank lines, but without its trailing newline.
+func prettyPrint(doc string) (string, error) {
+	parsed, err := schema.ParseQuery(doc)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := parsed.WriteGraphQL(&b); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// minify returns the document doc without any insignificant whitespace, i.e. the whitespace
+// that doesn't separate names, numbers, or keywords from each other. Strings are left as is.
+func minify(doc string) string {
+	var b strings.Builder
+	b.Grow(len(doc))
+
+	var last byte
+	space := false
+	for i := 0; i < len(doc); i++ {
+		c := doc[i]
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			space = true
+			continue
+		}
+
+		if space && isNameByte(last) && isNameByte(c) {
+			b.WriteByte(' ')
+		}
+		space = false
+
+		if c == '"' {
+			end := stringEnd(doc, i)
+			b.WriteString(doc[i:end])
+			i = end - 1
+			last = '"'
+			continue
+		}
+
+		b.WriteByte(c)
+		last = c
+	}
+
+	return b.String()
+}
+
+// isNameByte denotes whether or not c can be part of a name, number, or keyword, which need to
+// be separated from each other by whitespace.
+func isNameByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// stringEnd returns the index right after the end of the string, or block string, that starts
+// at the index start of doc.
+func stringEnd(doc string, start int) int {
+	if strings.HasPrefix(doc[start:], `"""`) {
+		for i := start + 3; i < len(doc); i++ {
+			switch {
+			case strings.HasPrefix(doc[i:], `\"""`):
+				i += 3
+			case strings.HasPrefix(doc[i:], `"""`):
+				return i + 3
+			}
+		}
+		return len(doc)
+	}
+
+	for i := start + 1; i < len(doc); i++ {
+		switch doc[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(doc)
+}
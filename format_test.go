@@ -0,0 +1,133 @@
+package goql
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMarshalQueryFormats(t *testing.T) {
+	t.Parallel()
+
+	type Query struct {
+		User struct {
+			TestUserSummary
+			Posts []struct {
+				Title string
+			} `goql:"posts(first:$first<Int>=10,search:\"a { b }, c\"),@include($withPosts)"`
+			Node struct {
+				OnUser struct {
+					Email string
+				} `goql:"... on User"`
+			} `goql:"node(id:$id<ID!>)"`
+		} `goql:"user(id:$id<ID!>)"`
+	}
+
+	tt := []struct {
+		Name     string
+		Option   marshalOption
+		Expected string
+	}{
+		{
+			Name: "Default",
+			Expected: `query($id: ID!, $first: Int = 10, $withPosts: Boolean!) {
+user(id: $id) {
+...UserSummary
+posts(first: $first, search: "a { b }, c") @include(if: $withPosts) {
+title
+}
+node(id: $id) {
+... on User {
+email
+}
+__typename
+}
+}
+}
+fragment UserSummary on User {
+id
+name
+}`,
+		},
+		{
+			Name:   "PrettyPrint",
+			Option: OptPrettyPrint,
+			Expected: `query ($id: ID!, $first: Int = 10, $withPosts: Boolean!) {
+  user(id: $id) {
+    ...UserSummary
+    posts(first: $first, search: "a { b }, c") @include(if: $withPosts) {
+      title
+    }
+    node(id: $id) {
+      ... on User {
+        email
+      }
+      __typename
+    }
+  }
+}
+
+fragment UserSummary on User {
+  id
+  name
+}`,
+		},
+		{
+			Name:   "Minify",
+			Option: OptMinify,
+			Expected: `query($id:ID!,$first:Int=10,$withPosts:Boolean!){user(id:$id){...UserSummary ` +
+				`posts(first:$first,search:"a { b }, c")@include(if:$withPosts){title}node(id:$id){...on User{email}__typename}}}` +
+				`fragment UserSummary on User{id name}`,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			got, err := MarshalQueryWithOptions(Query{}, nil, test.Option)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.Expected, got); diff != "" {
+				t.Errorf("unexpected query (-want +got):\n%s", diff)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestMinify(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		Name     string
+		Input    string
+		Expected string
+	}{
+		{
+			Name:     "Strings",
+			Input:    `{ search(text: "a \"b\" c", block: """ d "" e \""" f """) { id } }`,
+			Expected: `{search(text:"a \"b\" c",block:""" d "" e \""" f """){id}}`,
+		},
+		{
+			Name:     "Numbers",
+			Input:    "{\n users(ids: [1, 2], min: -1.5e3) @cached(ttl: 10) { id }\n}",
+			Expected: `{users(ids:[1,2],min:-1.5e3)@cached(ttl:10){id}}`,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			if got := minify(test.Input); got != test.Expected {
+				t.Errorf("expected %s, got %s", test.Expected, got)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
@@ -0,0 +1,204 @@
+package goql
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Fragment is the interface implemented by struct types that should be rendered as a named
+// fragment instead of being expanded inline wherever they're used. Take the following type
+// for example:
+//
+//	type UserSummary struct {
+//		ID   string
+//		Name string
+//	}
+//
+//	func (UserSummary) FragmentName() string { return "UserSummary" }
+//	func (UserSummary) FragmentOn() string   { return "User" }
+//
+// A struct field of type UserSummary renders as `author { ...UserSummary }`, while an embedded
+// UserSummary spreads the fragment into the enclosing selection as `...UserSummary`. In both
+// cases a single `fragment UserSummary on User { id name }` definition is appended to the
+// operation. If sparse fieldsets select different fields of the fragment in different places,
+// every distinct selection gets a definition of its own, which is named e.g. UserSummary_2.
+// The methods are called on the zero value of the type.
+type Fragment interface {
+	// FragmentName returns the name of the fragment definition.
+	FragmentName() string
+
+	// FragmentOn returns the type condition of the fragment definition, i.e. the name of the
+	// GraphQL type that the fragment selects fields on.
+	FragmentOn() string
+}
+
+// fragmentType is the reflect.Type of the Fragment interface.
+var fragmentType = reflect.TypeOf((*Fragment)(nil)).Elem()
+
+// namedFragment returns the name and type condition of t if t implements Fragment, either with
+// a value or a pointer receiver, itself rather than through an embedded struct field.
+func namedFragment(t reflect.Type) (name, on string, ok bool, err error) {
+	var fragment Fragment
+	switch {
+	case t.Implements(fragmentType):
+		fragment = reflect.Zero(t).Interface().(Fragment)
+	case reflect.PointerTo(t).Implements(fragmentType):
+		fragment = reflect.New(t).Interface().(Fragment)
+	default:
+		return "", "", false, nil
+	}
+
+	name, on = fragment.FragmentName(), fragment.FragmentOn()
+
+	// Structs that embed a named fragment implement Fragment through the promoted methods of
+	// it, that doesn't make them a named fragment themselves.
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			if sf := t.Field(i); sf.Anonymous {
+				embeddedName, embeddedOn, embedded, _ := namedFragment(deref(sf.Type)) //nolint:errcheck // Why: reported when walked
+				if embedded && embeddedName == name && embeddedOn == on {
+					return "", "", false, nil
+				}
+			}
+		}
+	}
+
+	if !reName.MatchString(name) || !reName.MatchString(on) {
+		return "", "", false, fmt.Errorf("invalid named fragment %q on %q defined by %s", name, on, t)
+	}
+
+	return name, on, true, nil
+}
+
+// isSpread denotes whether or not the field is a spread of a named fragment, in which case its
+// children make up the definition of that fragment.
+func (f *field) isSpread() bool {
+	return f.Decl.Fragment != ""
+}
+
+// document holds onto state that is shared by everything rendered into a single GraphQL
+// document, which are the definitions of the named fragments spread throughout it and the
+// variables used by the fields rendered into it.
+type document struct {
+	fragments map[string]fragmentDefinition
+	order     []string
+	variables []token
+}
+
+// use records the variables used by the declaration and directives of f, which is about to be
+// rendered into the document. It returns a mark that can be passed to unuse if f ends up not
+// being rendered after all.
+func (d *document) use(f *field) int {
+	mark := len(d.variables)
+	d.variables = append(d.variables, f.ownTokens()...)
+	return mark
+}
+
+// unuse forgets about all of the variables recorded since the given mark was returned by use.
+func (d *document) unuse(mark int) {
+	d.variables = d.variables[:mark]
+}
+
+// fragmentDefinition is the definition of a named fragment.
+type fragmentDefinition struct {
+	On     string
+	Fields string
+}
+
+// define adds the definition of a named fragment to the document and returns the name it's
+// defined under. If a fragment with the same name was already defined with different fields,
+// the definition is named after it with a numeric suffix instead, e.g. UserSummary_2, unless
+// the same definition was already added under such a name.
+func (d *document) define(name string, def fragmentDefinition) string {
+	if d.fragments == nil {
+		d.fragments = make(map[string]fragmentDefinition)
+	}
+
+	defined := name
+	for n := 2; ; n++ {
+		existing, exists := d.fragments[defined]
+		if !exists {
+			break
+		}
+
+		if existing == def {
+			return defined
+		}
+		defined = fmt.Sprintf("%s_%d", name, n)
+	}
+
+	d.fragments[defined] = def
+	d.order = append(d.order, defined)
+	return defined
+}
+
+// tokenizeDefinitions writes the definitions of all of the named fragments in the document, in
+// the order they were defined, to any type that implements the io.Writer interface.
+func (d *document) tokenizeDefinitions(w io.Writer) {
+	for _, name := range d.order {
+		def := d.fragments[name]
+		fmt.Fprintf(w, "\nfragment %s on %s {\n%s}", name, def.On, def.Fields) //nolint:errcheck
+	}
+}
+
+// writer is the io.Writer that fields are tokenized to, it holds onto the document that they
+// are being rendered into.
+type writer struct {
+	io.Writer
+	*document
+}
+
+// to returns a writer that writes to w but renders into the same document as the receiver.
+func (w *writer) to(out io.Writer) *writer {
+	return &writer{
+		Writer:   out,
+		document: w.document,
+	}
+}
+
+// tokenizeSpread writes a spread of the named fragment f to w, and adds its definition, whose
+// fields are written by tokenizeField, to the document of w. If no fields within the fragment
+// are written, nothing is. If the fragment was already defined with different fields, which
+// can happen when the sparse fieldsets differ between its uses, the spread is of a definition
+// of its own, see document.define.
+//
+// Returns a bool denoting whether or not the field was written and an error.
+func (f *field) tokenizeSpread(w *writer, tokenizeField func(*writer, *field) (bool, error)) (bool, error) {
+	var fields strings.Builder
+	var childWritten bool
+
+	mark := w.use(f)
+	fw := w.to(&fields)
+	for i := range f.Fields {
+		written, err := tokenizeField(fw, &f.Fields[i])
+		if err != nil {
+			return false, err
+		}
+
+		if written {
+			childWritten = true
+			io.WriteString(fw, "\n") //nolint:errcheck
+		}
+	}
+
+	if !childWritten {
+		w.unuse(mark)
+		return false, nil
+	}
+
+	def := fragmentDefinition{
+		On:     f.Decl.TypeCondition,
+		Fields: fields.String(),
+	}
+
+	fmt.Fprintf(w, "...%s", w.define(f.Decl.Fragment, def)) //nolint:errcheck
+
+	for _, directive := range f.Directives {
+		io.WriteString(w, " ") //nolint:errcheck
+		directive.tokenize(w)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,58 @@
+package goql
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+// defaultMaxGETURLLength is the default of ClientOptions.MaxGETURLLength, which is well within
+// the limits of browsers, proxies, and CDNs alike.
+const defaultMaxGETURLLength = 2048
+
+// getURL returns the URL that the body of req is sent to as a GET request, with the query,
+// operation name, variables, and extensions of body encoded in its query string. False is
+// returned if req should be sent as a POST request instead, either because the client doesn't
+// use GET requests, req isn't a query, or the URL would exceed the maximum length configured.
+func (c *Client) getURL(req *Request, body *request) (string, bool, error) {
+	if !c.useGETForQueries || req.operationType() != "query" {
+		return "", false, nil
+	}
+
+	u, err := url.Parse(c.url)
+	if err != nil {
+		return "", false, err
+	}
+
+	params := u.Query()
+	if body.Query != "" {
+		params.Set("query", body.Query)
+	}
+
+	if body.OperationName != "" {
+		params.Set("operationName", body.OperationName)
+	}
+
+	if body.Variables != nil {
+		variables, err := json.Marshal(body.Variables)
+		if err != nil {
+			return "", false, err
+		}
+		params.Set("variables", string(variables))
+	}
+
+	if body.Extensions != nil {
+		extensions, err := json.Marshal(body.Extensions)
+		if err != nil {
+			return "", false, err
+		}
+		params.Set("extensions", string(extensions))
+	}
+
+	u.RawQuery = params.Encode()
+
+	target := u.String()
+	if len(target) > c.maxGETURLLength {
+		return "", false, nil
+	}
+	return target, true, nil
+}
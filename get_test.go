@@ -0,0 +1,148 @@
+package goql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// TestGET tests that queries are sent as GET requests when the client is configured to.
+func TestGET(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		Name              string
+		Query             string
+		Options           ClientOptions
+		ExpectedMethod    string
+		ExpectedQuery     string
+		ExpectedExtension bool
+	}{
+		{
+			Name:           "Query",
+			Query:          "query GetUser($id: ID!) { user(id: $id) { id } }",
+			Options:        ClientOptions{UseGETForQueries: true},
+			ExpectedMethod: http.MethodGet,
+			ExpectedQuery:  "query GetUser($id: ID!) { user(id: $id) { id } }",
+		},
+		{
+			Name:           "Mutation",
+			Query:          "mutation ($id: ID!) { deleteUser(id: $id) { id } }",
+			Options:        ClientOptions{UseGETForQueries: true},
+			ExpectedMethod: http.MethodPost,
+		},
+		{
+			Name:           "MutationAfterComment",
+			Query:          "# Deletes a user.\nmutation ($id: ID!) { deleteUser(id: $id) { id } }",
+			Options:        ClientOptions{UseGETForQueries: true},
+			ExpectedMethod: http.MethodPost,
+		},
+		{
+			Name:           "MutationAfterFragment",
+			Query:          "fragment F on User { id } mutation ($id: ID!) { deleteUser(id: $id) { ...F } }",
+			Options:        ClientOptions{UseGETForQueries: true},
+			ExpectedMethod: http.MethodPost,
+		},
+		{
+			Name:           "URLTooLong",
+			Query:          "query GetUser($id: ID!) { user(id: $id) { id } }",
+			Options:        ClientOptions{UseGETForQueries: true, MaxGETURLLength: 64},
+			ExpectedMethod: http.MethodPost,
+		},
+		{
+			Name:              "PersistedQuery",
+			Query:             "query GetUser($id: ID!) { user(id: $id) { id } }",
+			Options:           ClientOptions{UseGETForQueries: true, PersistedQueries: true},
+			ExpectedMethod:    http.MethodGet,
+			ExpectedExtension: true,
+		},
+		{
+			Name:           "Disabled",
+			Query:          "query GetUser($id: ID!) { user(id: $id) { id } }",
+			Options:        DefaultClientOptions,
+			ExpectedMethod: http.MethodPost,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if e, a := test.ExpectedMethod, r.Method; e != a {
+					t.Errorf("expected method to be %s, got %s", e, a)
+				}
+
+				if r.Method == http.MethodGet {
+					params := r.URL.Query()
+
+					if e, a := test.ExpectedQuery, params.Get("query"); e != a {
+						t.Errorf("expected query to be %q, got %q", e, a)
+					}
+
+					if e, a := "GetUser", params.Get("operationName"); e != a {
+						t.Errorf("expected operation name to be %q, got %q", e, a)
+					}
+
+					if e, a := `{"id":"1"}`, params.Get("variables"); e != a {
+						t.Errorf("expected variables to be %s, got %s", e, a)
+					}
+
+					if e, a := test.ExpectedExtension, params.Get("extensions") != ""; e != a {
+						t.Errorf("expected extensions to be sent to be %t, got %t", e, a)
+					}
+				}
+
+				w.Header().Set("Content-Type", applicationJSON)
+				io.WriteString(w, `{"data":{}}`) //nolint:errcheck
+			}))
+			t.Cleanup(ts.Close)
+
+			reader := sdkmetric.NewManualReader()
+
+			options := test.Options
+			options.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+			client := NewClient(ts.URL, options)
+
+			req := Request{
+				Query:         test.Query,
+				OperationName: "GetUser",
+				Variables:     map[string]interface{}{"id": "1"},
+				Header:        http.Header{},
+			}
+
+			if _, err := client.do(context.Background(), &req, true); err != nil {
+				t.Fatalf("error performing operation: %v", err)
+			}
+
+			var rm metricdata.ResourceMetrics
+			if err := reader.Collect(context.Background(), &rm); err != nil {
+				t.Fatalf("error collecting metrics: %v", err)
+			}
+
+			// The size of the request is recorded whether it's sent as a GET or a POST request.
+			var sizes uint64
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					if h, ok := m.Data.(metricdata.Histogram[int64]); ok && m.Name == "goql.client.request.size" {
+						for _, dp := range h.DataPoints {
+							if dp.Sum > 0 {
+								sizes += dp.Count
+							}
+						}
+					}
+				}
+			}
+
+			if e, a := uint64(1), sizes; e != a {
+				t.Errorf("expected %d request sizes to be recorded, got %d", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
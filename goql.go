@@ -5,6 +5,10 @@ package goql
 import (
 	"context"
 	"net/http"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ErrorMapper is a type that is used for error mapping functions. The status code and Errors
@@ -19,6 +23,22 @@ type Client struct {
 	httpClient  *http.Client
 	errorMapper ErrorMapper
 	marshalOpts []marshalOption
+
+	allowPartialData bool
+	retryPolicy      RetryPolicy
+	middleware       []Middleware
+	telemetry        *telemetry
+	useGETForQueries bool
+	maxGETURLLength  int
+	batcher          *batcher
+
+	// persistedQueriesUnsupported is set once the GraphQL server responds that it doesn't
+	// support persisted queries, after which the client stops using them.
+	persistedQueries            bool
+	persistedQueriesUnsupported atomic.Bool
+
+	subscriptionURL         string
+	subscriptionInitPayload map[string]interface{}
 }
 
 // ClientOptions is the type passed to NewClient that allows for configuration of the client.
@@ -30,17 +50,83 @@ type Client struct {
 // mapped to a different type that implements the error interface, optionally. The status
 // code of the response from the GraphQL server is also passed to this function to attempt
 // to give more context to the callee. If omitted or nil the Errors type will be returned
-// in the case of any errors that came from the GraphQL server. See the documentation for
-// the Errors type for more information as to what can be done with this mapping function.
+// in the case of any errors that came from the GraphQL server, which works with errors.Is
+// and the classes of errors defined by this package, e.g. errors.Is(err, goql.ErrNotFound).
+// See the documentation for the Errors type for more information as to what can be done with
+// this mapping function.
 //
 // UseJSONTagNameAsFallback indicates whether goql should fall back on using the `json`
 // struct tags if there's no `goql` struct tags when marshaling a struct into a query. If
 // true, only the name of the field is inferred from the JSON struct tag, not any other
 // attribute such as alias, include, or keep. Default value is false.
+//
+// AllowPartialData, if true, makes the client decode the data of responses that come with
+// errors, as GraphQL servers regularly return partial results along with field-level errors.
+// The OperationType of Query and Mutate (or the resp of CustomOperation) is populated from
+// whatever data was returned and the errors, mapped through the ErrorMapper, are still returned
+// alongside it. Responses with errors and no data behave the same way regardless. Default value
+// is false.
+//
+// RetryPolicy configures how requests that failed in a way that is likely to be transient are
+// retried. By default requests are not retried, see the documentation of the RetryPolicy type
+// for more information.
+//
+// Middleware wraps every request performed through the client other than subscriptions, which
+// allows requests to be inspected or modified before they're sent and responses before they're
+// decoded, e.g. to inject authentication tokens, log, or record metrics. The first Middleware
+// is the outermost one, i.e. it sees requests first and responses last. See the documentation
+// of the Middleware type for more information.
+//
+// PersistedQueries, if true, makes the client use automatic persisted queries as implemented by
+// Apollo and other GraphQL servers. The SHA-256 hash of each query is sent in place of the query
+// itself, and the query is only sent along with its hash, for the GraphQL server to store it,
+// if the GraphQL server responds that it doesn't know the hash yet. This saves sending large
+// queries on every request. If the GraphQL server responds that it doesn't support persisted
+// queries at all, the client stops using them. Default value is false.
+//
+// UseGETForQueries, if true, makes the client send queries as GET requests, which unlike POST
+// requests can be cached by CDNs and HTTP caches. The query, operationName, variables, and
+// extensions of the request are URL-encoded into the query string of the URL. Mutations are
+// always sent as POST requests, even if their document starts with comments or fragments. When
+// used along with PersistedQueries, only the hash of the query is sent in the URL. Default value
+// is false.
+//
+// MaxGETURLLength is the maximum length of the URL of a GET request, queries whose URL would
+// exceed it are sent as POST requests instead. Defaults to 2048.
+//
+// Batching configures how operations performed concurrently through the client are batched
+// into a single request. By default operations are not batched, see the documentation of the
+// BatchOptions type for more information.
+//
+// TracerProvider and MeterProvider are the OpenTelemetry providers that the client traces and
+// records metrics of the operations it performs with. Each operation is traced with a client
+// span that carries the type, name, and SHA-256 hash of the GraphQL document of the operation,
+// but not its variables, and the duration, request and response sizes, and errors of operations
+// are recorded as metrics. Subscriptions are neither traced nor recorded, since they don't go
+// through the client's Middleware and last for as long as the server keeps sending payloads. If
+// omitted, the global providers of the otel package are used.
+//
+// SubscriptionURL is the WebSocket URL that subscriptions are performed against. If omitted,
+// the URL passed to NewClient is used with its scheme switched from http(s) to ws(s).
+//
+// SubscriptionInitPayload is sent as the payload of the connection_init message whenever a
+// subscription connection is established. This is commonly where servers expect authentication
+// information to be passed, since browsers can't set headers on WebSocket requests.
 type ClientOptions struct {
 	HTTPClient               *http.Client
 	ErrorMapper              ErrorMapper
 	UseJSONTagNameAsFallback bool
+	AllowPartialData         bool
+	RetryPolicy              RetryPolicy
+	Middleware               []Middleware
+	PersistedQueries         bool
+	UseGETForQueries         bool
+	MaxGETURLLength          int
+	Batching                 BatchOptions
+	TracerProvider           trace.TracerProvider
+	MeterProvider            metric.MeterProvider
+	SubscriptionURL          string
+	SubscriptionInitPayload  map[string]interface{}
 }
 
 // DefaultClientOptions is a variable that can be passed for the ClientOptions when calling
@@ -76,12 +162,36 @@ func NewClient(clientURL string, options ClientOptions) *Client {
 		marshOpts = append(marshOpts, OptFallbackJSONTag)
 	}
 
-	return &Client{
-		url:         clientURL,
-		httpClient:  options.HTTPClient,
-		errorMapper: options.ErrorMapper,
-		marshalOpts: marshOpts,
+	// If MaxGETURLLength was omitted, use defaultMaxGETURLLength.
+	if options.MaxGETURLLength <= 0 {
+		options.MaxGETURLLength = defaultMaxGETURLLength
+	}
+
+	// If SubscriptionURL was omitted, derive it from the URL of the GraphQL server.
+	if options.SubscriptionURL == "" {
+		options.SubscriptionURL = websocketURL(clientURL)
+	}
+
+	c := Client{
+		url:                     clientURL,
+		httpClient:              options.HTTPClient,
+		errorMapper:             options.ErrorMapper,
+		marshalOpts:             marshOpts,
+		allowPartialData:        options.AllowPartialData,
+		retryPolicy:             options.RetryPolicy,
+		middleware:              options.Middleware,
+		persistedQueries:        options.PersistedQueries,
+		useGETForQueries:        options.UseGETForQueries,
+		maxGETURLLength:         options.MaxGETURLLength,
+		telemetry:               newTelemetry(options.TracerProvider, options.MeterProvider),
+		subscriptionURL:         options.SubscriptionURL,
+		subscriptionInitPayload: options.SubscriptionInitPayload,
 	}
+
+	// The batcher depends on the telemetry of the client.
+	c.batcher = newBatcher(&c, options.Batching)
+
+	return &c
 }
 
 // QueryWithHeaders performs a query type of request to retrieve data from a GraphQL server. q should
@@ -116,6 +226,44 @@ func (c *Client) Mutate(ctx context.Context, operation *Operation) error {
 	return c.MutateWithHeaders(ctx, operation, nil)
 }
 
+// QueryAllWithHeaders performs several queries as a single request to a GraphQL server. The root
+// fields of the operations are selected side by side in a single query, aliased with a prefix
+// unique to each operation, and the variables of the operations are renamed whenever their names
+// collide with the ones of another operation. The data of each operation is decoded back into
+// its own OperationType, which should be passed by reference. Errors returned by the GraphQL
+// server aren't split between the operations, their paths start with the prefixed aliases.
+//
+// Only the root fields of the operations are composed, so the names of NamedOperations aren't
+// used and root fields can't be fragments. Nothing is sent if there are no operations.
+func (c *Client) QueryAllWithHeaders(ctx context.Context, operations []*Operation, headers http.Header) error {
+	if headers == nil {
+		headers = http.Header{}
+	}
+
+	return c.doComposed(ctx, opQuery, operations, headers)
+}
+
+// QueryAll is a wrapper around QueryAllWithHeaders that passes no headers.
+func (c *Client) QueryAll(ctx context.Context, operations ...*Operation) error {
+	return c.QueryAllWithHeaders(ctx, operations, nil)
+}
+
+// MutateAllWithHeaders performs several mutations as a single request to a GraphQL server, which
+// executes them in the order they're passed in. See QueryAllWithHeaders for how the operations
+// are composed.
+func (c *Client) MutateAllWithHeaders(ctx context.Context, operations []*Operation, headers http.Header) error {
+	if headers == nil {
+		headers = http.Header{}
+	}
+
+	return c.doComposed(ctx, opMutation, operations, headers)
+}
+
+// MutateAll is a wrapper around MutateAllWithHeaders that passes no headers.
+func (c *Client) MutateAll(ctx context.Context, operations ...*Operation) error {
+	return c.MutateAllWithHeaders(ctx, operations, nil)
+}
+
 // CustomOperationWithHeaders takes a query in the form of a string and attempts to marshal the response
 // into the resp parameter, which should be passed by reference (as a pointer). If nil is passed as the
 // actual parameter for the formal parameter resp, the response is discarded.
@@ -132,3 +280,24 @@ func (c *Client) CustomOperationWithHeaders(ctx context.Context, query string, v
 func (c *Client) CustomOperation(ctx context.Context, query string, variables map[string]interface{}, resp interface{}) error {
 	return c.CustomOperationWithHeaders(ctx, query, variables, resp, nil)
 }
+
+// SubscribeWithHeaders performs a subscription type of request against a GraphQL server over a
+// WebSocket using the graphql-transport-ws protocol. Each payload sent by the server is decoded
+// into a fresh value of the type of operation.OperationType, which must be passed by reference,
+// and handed to handler. The headers are sent along with the WebSocket upgrade request.
+//
+// SubscribeWithHeaders blocks until the server completes the subscription, ctx is canceled,
+// or handler returns an error, in which case that error is returned.
+func (c *Client) SubscribeWithHeaders(ctx context.Context, operation *Operation, headers http.Header,
+	handler SubscriptionHandler) error {
+	if headers == nil {
+		headers = http.Header{}
+	}
+
+	return c.doSubscription(ctx, operation, headers, handler)
+}
+
+// Subscribe is a wrapper around SubscribeWithHeaders that passes no headers.
+func (c *Client) Subscribe(ctx context.Context, operation *Operation, handler SubscriptionHandler) error {
+	return c.SubscribeWithHeaders(ctx, operation, nil, handler)
+}
@@ -2,8 +2,11 @@ package goql
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 	"time"
@@ -142,6 +145,120 @@ func TestQuery(t *testing.T) {
 	ts.DiffResponse(GetEntity.ExpectedResponse(), GetEntity)
 }
 
+// TestQueryNamedOperation tests that the Query pointer receiver function on the Client type
+// sends the name of named operations along as the operationName of the request.
+func TestQueryNamedOperation(t *testing.T) {
+	t.Parallel()
+
+	ts := graphql_test.NewServer(t, true)
+	t.Cleanup(ts.Close)
+
+	client := NewClient(ts.URL, DefaultClientOptions)
+
+	var GetNamedEntity graphql_test.GetNamedEntity
+	operation := Operation{
+		OperationType: &GetNamedEntity,
+		Fields:        nil,
+		Variables:     GetNamedEntity.Variables(),
+	}
+
+	if err := client.Query(context.Background(), &operation); err != nil {
+		t.Fatalf("error running query: %v", err)
+	}
+
+	ts.DiffResponse(GetNamedEntity.ExpectedResponse(), GetNamedEntity)
+}
+
+// TestQueryPartialData tests that the Query pointer receiver function on the Client type only
+// populates the OperationType of responses that come with errors if partial data is allowed,
+// and that the errors are returned either way.
+func TestQueryPartialData(t *testing.T) {
+	tt := []struct {
+		Name             string
+		AllowPartialData bool
+	}{
+		{
+			Name:             "Allowed",
+			AllowPartialData: true,
+		},
+		{
+			Name:             "NotAllowed",
+			AllowPartialData: false,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			ts := graphql_test.NewServer(t, true)
+			t.Cleanup(ts.Close)
+
+			client := NewClient(ts.URL, ClientOptions{
+				AllowPartialData: test.AllowPartialData,
+			})
+
+			var GetPartialEntity graphql_test.GetPartialEntity
+			operation := Operation{
+				OperationType: &GetPartialEntity,
+				Fields:        nil,
+				Variables:     GetPartialEntity.Variables(),
+			}
+
+			err := client.Query(context.Background(), &operation)
+
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected error of type Errors, got %v", err)
+			}
+
+			if e, a := GetPartialEntity.ExpectedErrors()[0].Message, errs.Error(); e != a {
+				t.Errorf("expected error to be \"%s\", got \"%s\"", e, a)
+			}
+
+			if test.AllowPartialData {
+				ts.DiffResponse(GetPartialEntity.ExpectedResponse(), GetPartialEntity)
+			} else {
+				ts.DiffResponse(graphql_test.GetPartialEntity{}, GetPartialEntity)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
+
+// TestQueryPartialDataDecodeError tests that the errors that come with partial data are returned
+// along with the error of decoding that data, rather than replaced by it.
+func TestQueryPartialDataDecodeError(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", applicationJSON)
+		io.WriteString(w, `{"data":{"user":{"id":1}},"errors":[{"message":"name unavailable","path":["user","name"]}]}`) //nolint:errcheck
+	}))
+	t.Cleanup(ts.Close)
+
+	client := NewClient(ts.URL, ClientOptions{AllowPartialData: true})
+
+	var q struct {
+		User struct {
+			ID   string
+			Name *string
+		}
+	}
+
+	err := client.Query(context.Background(), &Operation{OperationType: &q})
+
+	var errs Errors
+	if !errors.As(err, &errs) || errs[0].Message != "name unavailable" {
+		t.Errorf("expected the errors of the response, got %v", err)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Errorf("expected the error of decoding the data, got %v", err)
+	}
+}
+
 // TestMutateWithHeaders tests the MutateWithHeaders pointer receiver function on the Client
 // type. Since this is mostly a pass-through function to *Client.doStruct, this test is
 // intentionally kept simple.
@@ -242,3 +359,99 @@ func TestCustomOperation(t *testing.T) {
 
 	ts.DiffResponse(testOperation.ExpectedResponse(), testOperation)
 }
+
+// TestSubscribe tests the Subscribe pointer receiver function on the Client type.
+func TestSubscribe(t *testing.T) {
+	t.Parallel()
+
+	ts := graphql_test.NewServer(t, true)
+	t.Cleanup(ts.Close)
+
+	client := NewClient(ts.URL, DefaultClientOptions)
+
+	var EntityUpdated graphql_test.EntityUpdated
+	operation := Operation{
+		OperationType: &EntityUpdated,
+		Fields:        nil,
+		Variables:     EntityUpdated.Variables(),
+	}
+
+	var received []interface{}
+	if err := client.Subscribe(context.Background(), &operation, func(data interface{}) error {
+		received = append(received, data)
+		return nil
+	}); err != nil {
+		t.Fatalf("error running subscription: %v", err)
+	}
+
+	expected := EntityUpdated.ExpectedResponses()
+	if e, a := len(expected), len(received); e != a {
+		t.Fatalf("expected %d payloads, got %d", e, a)
+	}
+
+	for i := range expected {
+		if _, ok := received[i].(*graphql_test.EntityUpdated); !ok {
+			t.Fatalf("expected payload to be of type %T, got %T", &EntityUpdated, received[i])
+		}
+		ts.DiffResponse(expected[i], received[i])
+	}
+}
+
+// TestSubscribeStopped tests that returning an error from a SubscriptionHandler stops the
+// subscription and surfaces that error.
+func TestSubscribeStopped(t *testing.T) {
+	t.Parallel()
+
+	ts := graphql_test.NewServer(t, true)
+	t.Cleanup(ts.Close)
+
+	client := NewClient(ts.URL, DefaultClientOptions)
+
+	var EntityUpdated graphql_test.EntityUpdated
+	operation := Operation{
+		OperationType: &EntityUpdated,
+		Fields:        nil,
+		Variables:     EntityUpdated.Variables(),
+	}
+
+	stop := errors.New("stop")
+
+	var calls int
+	if err := client.Subscribe(context.Background(), &operation, func(_ interface{}) error {
+		calls++
+		return stop
+	}); !errors.Is(err, stop) {
+		t.Fatalf("expected error %v from subscription, got %v", stop, err)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected handler to be called once, got %d", calls)
+	}
+}
+
+// TestSubscribeError tests that an error message sent by the server is returned through the
+// ErrorMapper of the client.
+func TestSubscribeError(t *testing.T) {
+	t.Parallel()
+
+	ts := graphql_test.NewServer(t, true)
+	t.Cleanup(ts.Close)
+
+	client := NewClient(ts.URL, DefaultClientOptions)
+
+	operation := Operation{
+		OperationType: &struct {
+			FooBarBaz string
+		}{},
+	}
+
+	err := client.Subscribe(context.Background(), &operation, func(_ interface{}) error {
+		t.Error("unexpected payload for unknown subscription")
+		return nil
+	})
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected error of type Errors, got %v", err)
+	}
+}
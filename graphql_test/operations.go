@@ -31,6 +31,10 @@ type Operation struct {
 	// valid Identifier for the same operation given above would be myOperation(foo: $foo).
 	Identifier string
 
+	// OperationName, if set, is the operationName that has to be passed along with the
+	// operation whenever it is invoked on the Server for it to match.
+	OperationName string
+
 	// Variables represents the map of variables that should be passed along with the
 	// operation whenever it is invoked on the Server.
 	Variables map[string]interface{}
@@ -38,6 +42,27 @@ type Operation struct {
 	// Response represents the response that should be returned whenever the server makes
 	// a match on Operation.opType, Operation.Name, and Operation.Variables.
 	Response interface{}
+
+	// Errors represents the errors that should be returned alongside Response, which
+	// makes it a partial response.
+	Errors []ResponseError
+}
+
+// Subscription is a type that encompasses a subscription operation and the responses that are
+// sent, in order, to the client whenever it subscribes to it.
+type Subscription struct {
+	// Identifier helps identify the subscription in a request when coming through the Server.
+	// See the documentation of Operation.Identifier for more information.
+	Identifier string
+
+	// Variables represents the map of variables that should be passed along with the
+	// subscription whenever it is invoked on the Server.
+	Variables map[string]interface{}
+
+	// Responses represents the payloads that are sent, one after the other, whenever the
+	// server makes a match on Subscription.Identifier and Subscription.Variables. Once all of
+	// them are sent the subscription is completed by the server.
+	Responses []interface{}
 }
 
 // OperationError is a special type that brings together the properties that a
@@ -199,3 +224,103 @@ func (*GetEntity) Variables() map[string]interface{} {
 		"id": 1,
 	}
 }
+
+var QueryGetNamedEntity GetNamedEntity
+
+type GetNamedEntity struct {
+	Entity `goql:"entity(id:$id<ID!>)"`
+}
+
+// OperationName implements the goql.NamedOperation interface.
+func (GetNamedEntity) OperationName() string {
+	return "GetEntity"
+}
+
+func (*GetNamedEntity) operationName() string {
+	return "query GetEntity($id: ID!) {\nentity(id: $id)"
+}
+
+func (*GetNamedEntity) ExpectedResponse() Entity {
+	return Entity{
+		ID:         2,
+		FieldOne:   "foo",
+		FieldTwo:   "bar",
+		CreatedAt:  now,
+		ModifiedAt: now,
+	}
+}
+
+func (*GetNamedEntity) Variables() map[string]interface{} {
+	return map[string]interface{}{
+		"id": 2,
+	}
+}
+
+var QueryGetPartialEntity GetPartialEntity
+
+type GetPartialEntity struct {
+	Entity `goql:"partialEntity(id:$id<ID!>)"`
+}
+
+func (*GetPartialEntity) operationName() string {
+	return "partialEntity"
+}
+
+func (*GetPartialEntity) ExpectedResponse() Entity {
+	return Entity{
+		ID:         3,
+		FieldOne:   "foo",
+		CreatedAt:  now,
+		ModifiedAt: now,
+	}
+}
+
+func (*GetPartialEntity) ExpectedErrors() []ResponseError {
+	return []ResponseError{
+		{
+			Message: "fieldTwo is unavailable",
+			Path:    []interface{}{"partialEntity", "fieldTwo"},
+		},
+	}
+}
+
+func (*GetPartialEntity) Variables() map[string]interface{} {
+	return map[string]interface{}{
+		"id": 3,
+	}
+}
+
+var SubscriptionEntityUpdated EntityUpdated
+
+type EntityUpdated struct {
+	Entity `goql:"entityUpdated(id:$id<ID!>)"`
+}
+
+func (*EntityUpdated) operationName() string {
+	return "entityUpdated"
+}
+
+func (*EntityUpdated) ExpectedResponses() []Entity {
+	return []Entity{
+		{
+			ID:         1,
+			FieldOne:   "foo",
+			FieldTwo:   "bar",
+			CreatedAt:  now,
+			ModifiedAt: now,
+		},
+		{
+			ID:         1,
+			FieldOne:   "baz",
+			FieldTwo:   "quux",
+			CreatedAt:  now,
+			ModifiedAt: now,
+		},
+	}
+}
+
+func (*EntityUpdated) Variables() map[string]interface{} {
+	return map[string]interface{}{
+		"id": 1,
+	}
+}
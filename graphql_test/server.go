@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 )
 
@@ -19,9 +20,10 @@ import (
 type Server struct {
 	URL string
 
-	mutations []Operation
-	queries   []Operation
-	errors    []OperationError
+	mutations     []Operation
+	queries       []Operation
+	subscriptions []Subscription
+	errors        []OperationError
 
 	t      *testing.T
 	server *httptest.Server
@@ -48,6 +50,20 @@ func NewServer(t *testing.T, useDefaultOperations bool) *Server { //nolint:funle
 			Response:   QueryGetEntity.ExpectedResponse(),
 		})
 
+		s.RegisterQuery(Operation{
+			Identifier:    QueryGetNamedEntity.operationName(),
+			OperationName: QueryGetNamedEntity.OperationName(),
+			Variables:     QueryGetNamedEntity.Variables(),
+			Response:      QueryGetNamedEntity.ExpectedResponse(),
+		})
+
+		s.RegisterQuery(Operation{
+			Identifier: QueryGetPartialEntity.operationName(),
+			Variables:  QueryGetPartialEntity.Variables(),
+			Response:   QueryGetPartialEntity.ExpectedResponse(),
+			Errors:     QueryGetPartialEntity.ExpectedErrors(),
+		})
+
 		s.RegisterMutation(Operation{
 			Identifier: MutationCreateEntity.operationName(),
 			Variables:  MutationCreateEntity.Variables(),
@@ -65,10 +81,26 @@ func NewServer(t *testing.T, useDefaultOperations bool) *Server { //nolint:funle
 			Variables:  MutationDeleteEntity.Variables(),
 			Response:   MutationDeleteEntity.ExpectedResponse(),
 		})
+
+		responses := make([]interface{}, 0, len(SubscriptionEntityUpdated.ExpectedResponses()))
+		for _, response := range SubscriptionEntityUpdated.ExpectedResponses() {
+			responses = append(responses, response)
+		}
+
+		s.RegisterSubscription(Subscription{
+			Identifier: SubscriptionEntityUpdated.operationName(),
+			Variables:  SubscriptionEntityUpdated.Variables(),
+			Responses:  responses,
+		})
 	}
 
 	var mux http.ServeMux
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			s.serveSubscription(w, r)
+			return
+		}
+
 		var reqBody Request
 		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 			s.respondError(w, http.StatusInternalServerError, errors.Wrap(err, "decode request body"), nil)
@@ -78,18 +110,18 @@ func NewServer(t *testing.T, useDefaultOperations bool) *Server { //nolint:funle
 		switch {
 		case strings.HasPrefix(strings.TrimSpace(reqBody.Query), "mutation"):
 			for i := range s.mutations {
-				if strings.Contains(reqBody.Query, s.mutations[i].Identifier) {
+				if strings.Contains(reqBody.Query, s.mutations[i].Identifier) && s.matchOperationName(s.mutations[i], reqBody) {
 					if s.equalVariables(s.mutations[i].Variables, reqBody.Variables) {
-						s.respond(w, http.StatusOK, s.mutations[i].Response)
+						s.respond(w, http.StatusOK, s.mutations[i].Response, s.mutations[i].Errors)
 						return
 					}
 				}
 			}
 		case strings.HasPrefix(strings.TrimSpace(reqBody.Query), "query"):
 			for i := range s.queries {
-				if strings.Contains(reqBody.Query, s.queries[i].Identifier) {
+				if strings.Contains(reqBody.Query, s.queries[i].Identifier) && s.matchOperationName(s.queries[i], reqBody) {
 					if s.equalVariables(s.queries[i].Variables, reqBody.Variables) {
-						s.respond(w, http.StatusOK, s.queries[i].Response)
+						s.respond(w, http.StatusOK, s.queries[i].Response, s.queries[i].Errors)
 						return
 					}
 				}
@@ -112,6 +144,12 @@ func NewServer(t *testing.T, useDefaultOperations bool) *Server { //nolint:funle
 	return &s
 }
 
+// matchOperationName denotes whether or not the operationName of reqBody matches the one that
+// operation requires, if any.
+func (*Server) matchOperationName(operation Operation, reqBody Request) bool {
+	return operation.OperationName == "" || operation.OperationName == reqBody.OperationName
+}
+
 // Close closes the underlying httptest.Server.
 func (s *Server) Close() {
 	s.server.Close()
@@ -128,6 +166,12 @@ func (s *Server) Queries() []Operation {
 	return s.queries
 }
 
+// Subscriptions returns the registered subscriptions that the server will accept and
+// respond to.
+func (s *Server) Subscriptions() []Subscription {
+	return s.subscriptions
+}
+
 // RegisterQuery registers an Operation as a query that the server will recognize and
 // respond to.
 func (s *Server) RegisterQuery(operation Operation) {
@@ -142,6 +186,12 @@ func (s *Server) RegisterMutation(operation Operation) {
 	s.mutations = append(s.mutations, operation)
 }
 
+// RegisterSubscription registers a Subscription that the server will recognize and respond
+// to over a graphql-transport-ws WebSocket connection.
+func (s *Server) RegisterSubscription(subscription Subscription) {
+	s.subscriptions = append(s.subscriptions, subscription)
+}
+
 // RegisterError registers an OperationError as an error that the server will recognize
 // and respond to.
 func (s *Server) RegisterError(operation OperationError) {
@@ -0,0 +1,105 @@
+package graphql_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// message is the envelope of every message sent over a graphql-transport-ws connection.
+type message struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// upgrader upgrades incoming subscription requests to graphql-transport-ws connections.
+var upgrader = websocket.Upgrader{
+	Subprotocols: []string{"graphql-transport-ws"},
+}
+
+// serveSubscription speaks the server side of the graphql-transport-ws protocol. It answers the
+// first subscribe message it receives with the responses of the matching registered Subscription
+// and completes it afterwards, or with an error message if no registered Subscription matches.
+func (s *Server) serveSubscription(w http.ResponseWriter, r *http.Request) { //nolint:funlen
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.t.Errorf("upgrade subscription connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	for {
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil {
+			// The client closing the connection is the normal way for it to end.
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			if err := conn.WriteJSON(message{Type: "connection_ack"}); err != nil {
+				s.t.Errorf("write connection_ack: %v", err)
+				return
+			}
+		case "ping":
+			if err := conn.WriteJSON(message{Type: "pong"}); err != nil {
+				s.t.Errorf("write pong: %v", err)
+				return
+			}
+		case "subscribe":
+			var reqBody Request
+			if err := json.Unmarshal(msg.Payload, &reqBody); err != nil {
+				s.t.Errorf("decode subscribe payload: %v", err)
+				return
+			}
+
+			s.respondSubscription(conn, msg.ID, reqBody)
+		case "complete":
+			return
+		}
+	}
+}
+
+// respondSubscription writes the responses of the registered Subscription that matches reqBody
+// to conn, or an error message if there is none.
+func (s *Server) respondSubscription(conn *websocket.Conn, id string, reqBody Request) {
+	if strings.HasPrefix(strings.TrimSpace(reqBody.Query), "subscription") {
+		for i := range s.subscriptions {
+			if !strings.Contains(reqBody.Query, s.subscriptions[i].Identifier) ||
+				!s.equalVariables(s.subscriptions[i].Variables, reqBody.Variables) {
+				continue
+			}
+
+			for _, data := range s.subscriptions[i].Responses {
+				payload, err := json.Marshal(Response{Data: data})
+				if err != nil {
+					s.t.Errorf("encode subscription payload: %v", err)
+					return
+				}
+
+				if err := conn.WriteJSON(message{ID: id, Type: "next", Payload: payload}); err != nil {
+					s.t.Errorf("write next: %v", err)
+					return
+				}
+			}
+
+			if err := conn.WriteJSON(message{ID: id, Type: "complete"}); err != nil {
+				s.t.Errorf("write complete: %v", err)
+			}
+			return
+		}
+	}
+
+	payload, err := json.Marshal([]ResponseError{{Message: "operation not found"}})
+	if err != nil {
+		s.t.Errorf("encode subscription error payload: %v", err)
+		return
+	}
+
+	if err := conn.WriteJSON(message{ID: id, Type: "error", Payload: payload}); err != nil {
+		s.t.Errorf("write error: %v", err)
+	}
+}
@@ -6,14 +6,15 @@ import (
 )
 
 type Request struct {
-	Query     string                 `json:"query"`
-	Variables map[string]interface{} `json:"variables"`
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables"`
 }
 
 type ResponseError struct {
-	Message    string      `json:"message"`
-	Path       []string    `json:"path"`
-	Extensions interface{} `json:"extensions"`
+	Message    string        `json:"message"`
+	Path       []interface{} `json:"path"`
+	Extensions interface{}   `json:"extensions"`
 }
 
 type Response struct {
@@ -39,12 +40,12 @@ func (s *Server) respondError(w http.ResponseWriter, status int, err error, exte
 	}
 }
 
-func (s *Server) respond(w http.ResponseWriter, status int, data interface{}) {
+func (s *Server) respond(w http.ResponseWriter, status int, data interface{}, errs []ResponseError) {
 	s.t.Helper()
 
 	res := Response{
 		Data:   data,
-		Errors: nil,
+		Errors: errs,
 	}
 
 	w.WriteHeader(status)
@@ -0,0 +1,26 @@
+package goql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/getoutreach/goql/schema"
+)
+
+// IntrospectWithHeaders queries the schema of the GraphQL server through its introspection
+// system, see schema.IntrospectionQuery. The schema it returns can be saved to a file with
+// schema.Schema.Save and loaded back with schema.Load, which allows it to be used offline.
+func (c *Client) IntrospectWithHeaders(ctx context.Context, headers http.Header) (*schema.Schema, error) {
+	var data json.RawMessage
+	if err := c.CustomOperationWithHeaders(ctx, schema.IntrospectionQuery, nil, &data, headers); err != nil {
+		return nil, err
+	}
+
+	return schema.FromIntrospection(data)
+}
+
+// Introspect is a wrapper around IntrospectWithHeaders that passes no headers.
+func (c *Client) Introspect(ctx context.Context) (*schema.Schema, error) {
+	return c.IntrospectWithHeaders(ctx, nil)
+}
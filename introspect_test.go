@@ -0,0 +1,56 @@
+package goql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getoutreach/goql/schema"
+)
+
+// TestIntrospect tests that the schema of a GraphQL server is decoded from the response to the
+// introspection query.
+func TestIntrospect(t *testing.T) {
+	t.Parallel()
+
+	expected, err := schema.ParseSDL(`
+type Query {
+  user(id: ID!): User
+}
+
+type User {
+  id: ID!
+  name: String
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query != schema.IntrospectionQuery {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", applicationJSON)
+		expected.WriteJSON(w) //nolint:errcheck
+	}))
+	t.Cleanup(ts.Close)
+
+	s, err := NewClient(ts.URL, ClientOptions{}).Introspect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := s.RootType("query").Field("user")
+	if user == nil || user.Type.String() != "User" || user.Arg("id").Type.String() != "ID!" {
+		t.Errorf("unexpected field user: %+v", user)
+	}
+
+	if name := s.Type("User").Field("name"); name == nil || name.Type.Kind != schema.KindScalar {
+		t.Errorf("unexpected field name: %+v", name)
+	}
+}
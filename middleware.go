@@ -0,0 +1,90 @@
+package goql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// errNoResponse is returned in place of a nil error by Handlers that return a nil Response.
+var errNoResponse = errors.New("goql: handler returned neither a response nor an error")
+
+// Request is a GraphQL request as seen by Middleware, before it is sent to the GraphQL server.
+type Request struct {
+	// Query is the rendered GraphQL operation.
+	Query string
+
+	// OperationName is the name of the operation, see NamedOperation. It's empty for anonymous
+	// operations.
+	OperationName string
+
+	// Variables holds onto the values of the variables used throughout the operation.
+	Variables map[string]interface{}
+
+	// Header holds onto the headers that are sent along with the request.
+	Header http.Header
+
+	// opQuery, opName, and opType hold onto the type of the operation of the request, along
+	// with the query and operation name it was determined from, see operationType.
+	opQuery, opName, opType string
+}
+
+// operationType returns the type of the operation of r, see operationType. It's only determined
+// again if the query or the operation name of r changed since, e.g. through Middleware.
+func (r *Request) operationType() string {
+	if r.opType == "" || r.opQuery != r.Query || r.opName != r.OperationName {
+		r.opQuery, r.opName, r.opType = r.Query, r.OperationName, operationType(r.Query, r.OperationName)
+	}
+	return r.opType
+}
+
+// Response is a GraphQL response as seen by Middleware, after it is received from the GraphQL
+// server and before its data is decoded.
+type Response struct {
+	// Data uses json.RawMessage to delay decoding of itself since we don't
+	// know the type of it at compile time.
+	Data json.RawMessage `json:"data"`
+
+	// Errors holds onto the errors returned in the response, they're passed through the
+	// ErrorMapper of the client after all of the middleware is done with the response.
+	Errors Errors `json:"errors,omitempty"`
+
+	// StatusCode is the status code of the HTTP response that the GraphQL response came in.
+	StatusCode int `json:"-"`
+
+	// Header holds onto the headers of the HTTP response that the GraphQL response came in.
+	Header http.Header `json:"-"`
+
+	// size is the size of the body of the HTTP response, in bytes.
+	size int64
+
+	// batch holds onto the responses to each of the operations of a batch, in the same order
+	// they were sent in, if the response is to a batch of operations.
+	batch []Response
+}
+
+// Handler is the type of the function that performs a GraphQL request and returns its response.
+// Errors that prevent a GraphQL response from being received, e.g. network errors or an
+// *HTTPError, are returned as the error, while errors returned in the GraphQL response are part
+// of the Response. A Handler must return either a non-nil Response or a non-nil error, e.g.
+// Middleware that short-circuits requests with a cache or a mock must return a Response. Should
+// both be nil, the request fails with an error regardless.
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// Middleware is the type of the function that wraps the Handler that performs each GraphQL
+// request of a client, see ClientOptions.Middleware. It can inspect or modify the request before
+// passing it on to next, and inspect or modify the response that comes back from it, e.g.:
+//
+//	func Logging(next goql.Handler) goql.Handler {
+//		return func(ctx context.Context, req *goql.Request) (*goql.Response, error) {
+//			start := time.Now()
+//			resp, err := next(ctx, req)
+//			log.Info(ctx, "graphql request", log.F{"operation": req.OperationName, "took": time.Since(start)})
+//			return resp, err
+//		}
+//	}
+//
+// Middleware is called once per operation performed through the client, retries happen within
+// the Handler that it wraps. It doesn't apply to subscriptions.
+type Middleware func(next Handler) Handler
@@ -0,0 +1,127 @@
+package goql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestMiddleware tests that the middleware of a client wraps the requests it performs in order.
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first attempt to make sure middleware is called once regardless of retries.
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", applicationJSON)
+		io.WriteString(w, `{"data":{"token":"`+r.Header.Get("Authorization")+`"}}`) //nolint:errcheck
+	}))
+	t.Cleanup(ts.Close)
+
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) (*Response, error) {
+				calls = append(calls, name+" request")
+				resp, err := next(ctx, req)
+				calls = append(calls, name+" response")
+				return resp, err
+			}
+		}
+	}
+
+	var seen Request
+	auth := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			seen = *req
+			req.Header.Set("Authorization", "Bearer token")
+			return next(ctx, req)
+		}
+	}
+
+	rewrite := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			resp, err := next(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+
+			if e, a := http.StatusOK, resp.StatusCode; e != a {
+				t.Errorf("expected status code to be %d, got %d", e, a)
+			}
+
+			resp.Data = json.RawMessage(strings.Replace(string(resp.Data), "Bearer ", "", 1))
+			return resp, nil
+		}
+	}
+
+	client := NewClient(ts.URL, ClientOptions{
+		RetryPolicy: RetryPolicy{
+			MaxAttempts:    2,
+			InitialBackoff: time.Millisecond,
+		},
+		Middleware: []Middleware{record("outer"), record("inner"), auth, rewrite},
+	})
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+
+	variables := map[string]interface{}{"id": "1"}
+	if err := client.CustomOperation(context.Background(), "query ($id: ID!) { token(id: $id) }", variables, &resp); err != nil {
+		t.Fatalf("error performing operation: %v", err)
+	}
+
+	if e, a := "token", resp.Token; e != a {
+		t.Errorf("expected token to be %q, got %q", e, a)
+	}
+
+	if e, a := "query ($id: ID!) { token(id: $id) }", seen.Query; e != a {
+		t.Errorf("expected middleware to see query %q, got %q", e, a)
+	}
+
+	if e, a := variables, seen.Variables; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected middleware to see variables %v, got %v", e, a)
+	}
+
+	expectedCalls := []string{"outer request", "inner request", "inner response", "outer response"}
+	if e, a := expectedCalls, calls; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected middleware calls to be %v, got %v", e, a)
+	}
+
+	if e, a := int32(2), atomic.LoadInt32(&attempts); e != a {
+		t.Errorf("expected %d attempts, got %d", e, a)
+	}
+}
+
+// TestMiddlewareNoResponse tests that middleware returning neither a response nor an error fails
+// the request rather than the client.
+func TestMiddlewareNoResponse(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient("http://localhost", ClientOptions{
+		Middleware: []Middleware{func(Handler) Handler {
+			return func(context.Context, *Request) (*Response, error) {
+				return nil, nil //nolint:nilnil // Why: this is the misbehaving middleware under test.
+			}
+		}},
+	})
+
+	err := client.CustomOperation(context.Background(), "query { foo }", nil, nil)
+	if !errors.Is(err, errNoResponse) {
+		t.Errorf("expected %v, got %v", errNoResponse, err)
+	}
+}
@@ -0,0 +1,61 @@
+package goql
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// NamedOperation is the interface implemented by the wrapper struct types of operations that
+// should be rendered as named operations instead of anonymous ones. Take the following type for
+// example:
+//
+//	type GetUser struct {
+//		User struct {
+//			ID   string
+//			Name string
+//		} `goql:"user(id:$id<ID!>)"`
+//	}
+//
+//	func (GetUser) OperationName() string { return "GetUser" }
+//
+// It renders as `query GetUser($id: ID!) { user(id: $id) { id name } }`, and the Client sends
+// the name along as the operationName of the request, which lets servers and the tooling around
+// them tell operations apart. The method is called on the zero value of the type.
+type NamedOperation interface {
+	// OperationName returns the name of the operation.
+	OperationName() string
+}
+
+// namedOperationType is the reflect.Type of the NamedOperation interface.
+var namedOperationType = reflect.TypeOf((*NamedOperation)(nil)).Elem()
+
+// operationName returns the name of the operation q, which is passed either by value or by
+// reference, if its type implements NamedOperation with either a value or a pointer receiver.
+// Otherwise an empty string is returned, denoting an anonymous operation.
+func operationName(q interface{}) (string, error) {
+	t := reflect.TypeOf(q)
+	if t == nil {
+		return "", nil
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var operation NamedOperation
+	switch {
+	case t.Implements(namedOperationType):
+		operation = reflect.Zero(t).Interface().(NamedOperation)
+	case reflect.PointerTo(t).Implements(namedOperationType):
+		operation = reflect.New(t).Interface().(NamedOperation)
+	default:
+		return "", nil
+	}
+
+	name := operation.OperationName()
+	if !reName.MatchString(name) {
+		return "", fmt.Errorf("invalid operation name %q defined by %s", name, t)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,60 @@
+package goql
+
+// persistedQueryVersion is the version of the automatic persisted queries protocol that the
+// client implements.
+const persistedQueryVersion = 1
+
+// Messages and codes of the errors that GraphQL servers respond with when persisted queries
+// can't be used.
+const (
+	// persistedQueryNotFoundMessage is the message of the error returned when the GraphQL server
+	// doesn't know the hash of a persisted query yet.
+	persistedQueryNotFoundMessage = "PersistedQueryNotFound"
+
+	// persistedQueryNotFoundCode is the code of the error returned when the GraphQL server doesn't
+	// know the hash of a persisted query yet.
+	persistedQueryNotFoundCode = "PERSISTED_QUERY_NOT_FOUND"
+
+	// persistedQueryNotSupportedMessage is the message of the error returned when the GraphQL
+	// server doesn't support persisted queries.
+	persistedQueryNotSupportedMessage = "PersistedQueryNotSupported"
+
+	// persistedQueryNotSupportedCode is the code of the error returned when the GraphQL server
+	// doesn't support persisted queries.
+	persistedQueryNotSupportedCode = "PERSISTED_QUERY_NOT_SUPPORTED"
+)
+
+// extensions is the type that contains the structure of the extensions of a request that a
+// GraphQL server expects.
+type extensions struct {
+	PersistedQuery *persistedQuery `json:"persistedQuery,omitempty"`
+}
+
+// persistedQuery identifies a query by its hash, which is sent in place of the query itself
+// when using automatic persisted queries.
+type persistedQuery struct {
+	Version    int    `json:"version"`
+	SHA256Hash string `json:"sha256Hash"`
+}
+
+// persistedQueryNotFound denotes whether or not errs say that the GraphQL server doesn't know the
+// hash of a persisted query yet.
+func persistedQueryNotFound(errs Errors) bool {
+	return hasError(errs, persistedQueryNotFoundMessage, persistedQueryNotFoundCode)
+}
+
+// persistedQueryNotSupported denotes whether or not errs say that the GraphQL server doesn't
+// support persisted queries.
+func persistedQueryNotSupported(errs Errors) bool {
+	return hasError(errs, persistedQueryNotSupportedMessage, persistedQueryNotSupportedCode)
+}
+
+// hasError denotes whether or not any of errs has the given message or code.
+func hasError(errs Errors, message, code string) bool {
+	for i := range errs {
+		if errs[i].Message == message || errs[i].Code() == code {
+			return true
+		}
+	}
+	return false
+}
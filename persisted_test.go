@@ -0,0 +1,141 @@
+package goql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestPersistedQueries tests that the client sends the hashes of queries in place of them and
+// only falls back on sending queries when the GraphQL server asks for it.
+func TestPersistedQueries(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		Name string
+
+		// Supported denotes whether or not the GraphQL server supports persisted queries.
+		Supported bool
+
+		// ExpectedRequests is the requests the GraphQL server expects to receive over the course
+		// of two operations, where true denotes that the request contains the query itself.
+		ExpectedRequests []bool
+	}{
+		{
+			Name:             "Supported",
+			Supported:        true,
+			ExpectedRequests: []bool{false, true, false},
+		},
+		{
+			Name:             "NotSupported",
+			Supported:        false,
+			ExpectedRequests: []bool{false, true, true},
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			var mu sync.Mutex
+			var requests []bool
+			stored := make(map[string]string)
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body request
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("error decoding request: %v", err)
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				requests = append(requests, body.Query != "")
+
+				w.Header().Set("Content-Type", applicationJSON)
+
+				switch {
+				case body.Extensions == nil:
+				case !test.Supported:
+					io.WriteString(w, `{"errors":[{"message":"PersistedQueryNotSupported"}]}`) //nolint:errcheck
+					return
+				case body.Query != "":
+					if e, a := documentHash(body.Query), body.Extensions.PersistedQuery.SHA256Hash; e != a {
+						t.Errorf("expected hash to be %q, got %q", e, a)
+					}
+					stored[body.Extensions.PersistedQuery.SHA256Hash] = body.Query
+				case stored[body.Extensions.PersistedQuery.SHA256Hash] == "":
+					io.WriteString(w, `{"errors":[{"message":"PersistedQueryNotFound",`+ //nolint:errcheck
+						`"extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`)
+					return
+				}
+
+				io.WriteString(w, `{"data":{"user":{"id":"1"}}}`) //nolint:errcheck
+			}))
+			t.Cleanup(ts.Close)
+
+			client := NewClient(ts.URL, ClientOptions{PersistedQueries: true})
+
+			for i := 0; i < 2; i++ {
+				var q struct {
+					User struct {
+						ID string
+					} `goql:"user(id:$id<ID!>)"`
+				}
+
+				if err := client.Query(context.Background(), &Operation{
+					OperationType: &q,
+					Variables:     map[string]interface{}{"id": "1"},
+				}); err != nil {
+					t.Fatalf("error performing query: %v", err)
+				}
+
+				if e, a := "1", q.User.ID; e != a {
+					t.Errorf("expected id to be %q, got %q", e, a)
+				}
+			}
+
+			if e, a := test.ExpectedRequests, requests; !reflect.DeepEqual(e, a) {
+				t.Errorf("expected requests with queries to be %v, got %v", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
+
+// TestDocumentHashCached tests that the hash of a marshaled document is only computed once.
+func TestDocumentHashCached(t *testing.T) {
+	t.Parallel()
+
+	type HashedQuery struct {
+		User struct {
+			ID string
+		} `goql:"user(id:$id<ID!>)"`
+	}
+
+	query, err := MarshalQuery(HashedQuery{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := sha256Hex(query), documentHash(query); e != a {
+		t.Fatalf("expected hash to be %q, got %q", e, a)
+	}
+
+	// Marshaling the same document again must neither replace its digest nor compute its hash
+	// again, which would overwrite the sentinel.
+	v, _ := hashes.Load(query)
+	v.(*documentDigest).hash = "sentinel"
+
+	if _, err := MarshalQuery(HashedQuery{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := "sentinel", documentHash(query); e != a {
+		t.Errorf("expected hash to be computed once, got %q", a)
+	}
+}
@@ -1,6 +1,8 @@
 package goql
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -21,69 +23,88 @@ var (
 	// e.g. id
 	reName = regexp.MustCompile(`^\w+$`)
 
-	// reDecl matches a model name with arguments and their types.
-	// e.g. getUser(name:$name<String!>,age:$age<Int>,alias:$alias<[String!]!>)
-	reDecl     = regexp.MustCompile(`^(?P<name>\w+)(?P<args>\((?:\w+:\$\w+<\[?\w+!?]?!?>,?)*\))$`)
-	reDeclName = reDecl.SubexpIndex("name")
-	reDeclArgs = reDecl.SubexpIndex("args")
-
-	// reParam extracts parameters from a model name with arguments and their types (reDecl).
-	// e.g. getUser(name:$name<String!>,age:$age<Int>) -> [name:$name<String!>, age:$age<Int>]
-	reParam     = regexp.MustCompile(`(?P<name>\w+):\$(?P<arg>\w+)<(?P<kind>\[?\w+!?]?!?)>`)
-	reParamName = reParam.SubexpIndex("name")
-	reParamArg  = reParam.SubexpIndex("arg")
-	reParamKind = reParam.SubexpIndex("kind")
-
-	// reDirective matches a skip, include, or alias directive with arguments. It's worth noting
-	// here that an alias isn't actually a directive in GraphQL, but it's easiest to deal with it
-	// as if it were one here.
-	// e.g. @alias(fieldAlias) | @include($includeName) | @skip($skipID)
-	reDirective     = regexp.MustCompile(`^@(?P<name>\w+)(?P<arg>\(\$?\w+\))$`)
+	// reDecl matches the start of a model name with arguments, the arguments themselves are
+	// parsed by parseDecl.
+	// e.g. getUser(name:$name<String!>,first:10,filter:{active:true})
+	reDecl = regexp.MustCompile(`^\w+\s*\(`)
+
+	// reDirective matches a directive with optional arguments, the arguments of directives other
+	// than skip, include, and alias are parsed by parseDirective. It's worth noting here that an
+	// alias isn't actually a directive in GraphQL, but it's easiest to deal with it as if it were
+	// one here.
+	// e.g. @alias(fieldAlias) | @include($includeName) | @skip($skipID) | @defer | @cacheControl(maxAge:60)
+	reDirective     = regexp.MustCompile(`^@(?P<name>\w+)\s*(?P<arg>\(.*\))?$`)
 	reDirectiveName = reDirective.SubexpIndex("name")
 	reDirectiveArg  = reDirective.SubexpIndex("arg")
+
+	// reDirectiveShorthand matches the single argument that is passed to skip, include, and
+	// alias directives.
+	// e.g. ($includeName) | (fieldAlias)
+	reDirectiveShorthand = regexp.MustCompile(`^\(\$?\w+\)$`)
+
+	// reFragment matches an inline fragment along with its type condition.
+	// e.g. ... on User
+	reFragment     = regexp.MustCompile(`^\.\.\.\s*on\s+(?P<type>\w+)$`)
+	reFragmentType = reFragment.SubexpIndex("type")
 )
 
 // keep tag is used to denote a field that is always kept despite whatever the sparse fieldset
 // information says.
 const keepTag = "keep"
 
-// token represents arguments and variables used throughout a GraphQL query.
+// typenameField is the name of the meta field that GraphQL servers resolve to the name of the
+// concrete type of an object.
+const typenameField = "__typename"
+
+// token represents a variable used throughout a GraphQL query.
 type token struct {
 	Kind string
-	Name string
 	Arg  string
-}
 
-// tokenize takes a slice of tokens and returns a string representation of them.
-func tokenize(tokens []token) string {
-	tn := make([]string, 0, len(tokens))
-	for _, t := range tokens {
-		tn = append(tn, fmt.Sprintf("%s: $%s", t.Name, t.Arg))
-	}
-	return strings.Join(tn, ", ")
+	// Default is the rendered default value of the variable, if it has one.
+	Default string
 }
 
 // declaration is a data structure that represents a field or model in a GraphQL
 // operation.
 type declaration struct {
-	Name     string
-	Alias    string
-	Tokens   []token
-	Template string
+	Name      string
+	Alias     string
+	Arguments arguments
+
+	// TypeCondition, if set, denotes that the declaration is an inline fragment on the
+	// named type rather than a field, e.g. ... on User.
+	TypeCondition string
+
+	// Fragment, if set, denotes that the declaration is a spread of the named fragment
+	// rather than a field, e.g. ...UserSummary. TypeCondition is set along with it.
+	Fragment string
 }
 
 // tokenize is a receiver function of the Declaration type which takes the information
 // contained within and writes it to any type that implements the io.Writer interface.
 func (d declaration) tokenize(w io.Writer) {
-	if d.Alias != "" {
-		fmt.Fprintf(w, "%s: ", d.Alias) //nolint:errcheck
+	if d.Fragment != "" {
+		fmt.Fprintf(w, "...%s", d.Fragment) //nolint:errcheck
+		return
 	}
 
-	if d.Template != "" {
-		fmt.Fprintf(w, "%s(%s)", d.Name, tokenize(d.Tokens)) //nolint:errcheck
+	if d.TypeCondition != "" {
+		fmt.Fprintf(w, "... on %s", d.TypeCondition) //nolint:errcheck
 		return
 	}
+
+	if d.Alias != "" {
+		fmt.Fprintf(w, "%s: ", d.Alias) //nolint:errcheck
+	}
+
 	io.WriteString(w, d.Name) //nolint:errcheck
+
+	if len(d.Arguments) > 0 {
+		io.WriteString(w, "(") //nolint:errcheck
+		d.Arguments.tokenize(w)
+		io.WriteString(w, ")") //nolint:errcheck
+	}
 }
 
 // directiveEnum is an alias to a string that has distinct constant values defined
@@ -103,12 +124,27 @@ type directive struct {
 	Type     directiveEnum
 	Token    token
 	Template string
+
+	// Arguments holds onto the arguments of any directive other than skip, include,
+	// and alias, e.g. @cacheControl(maxAge: 60).
+	Arguments arguments
 }
 
 // tokenize is a receiver function of the Directive type which takes the information
 // contained within and writes it to any type that implements the io.Writer interface.
 func (d *directive) tokenize(w io.Writer) {
-	fmt.Fprintf(w, "@%s(if: $%s)", d.Type, d.Token.Arg) //nolint:errcheck
+	switch d.Type { //nolint:exhaustive // Why: any other directive is rendered as is.
+	case directiveInclude, directiveSkip:
+		fmt.Fprintf(w, "@%s(if: $%s)", d.Type, d.Token.Arg) //nolint:errcheck
+	default:
+		fmt.Fprintf(w, "@%s", d.Type) //nolint:errcheck
+
+		if len(d.Arguments) > 0 {
+			io.WriteString(w, "(") //nolint:errcheck
+			d.Arguments.tokenize(w)
+			io.WriteString(w, ")") //nolint:errcheck
+		}
+	}
 }
 
 // field is a data structure that represents a field or model in a GraphQL query.
@@ -120,23 +156,61 @@ type field struct {
 	// Keep, if set to true, tells the marshaling process to ignore whatever is
 	// contained in the sparse fieldset information about the current field and
 	// to always render it. Keep is automatically set to true if the marshaler
-	// detects that the current field is an operation declaration.
+	// detects that the current field is an operation declaration, i.e. a
+	// top-level model with arguments. Arguments on nested fields don't imply it.
 	Keep bool
 }
 
-// tokens recurses through a field to gather all tokens contained within the root
-// field as well as all of it's children fields.
-func (f *field) tokens() []token {
-	var tokens []token
+// isFragment denotes whether or not the field is an inline fragment or a spread of a named
+// fragment.
+func (f *field) isFragment() bool {
+	return f.Decl.TypeCondition != ""
+}
 
-	// Get the tokens from the declaration and directives of the current token.
-	tokens = append(tokens, f.Decl.Tokens...)
+// addTypenames recurses through a field and adds a __typename field, which is always kept, to
+// every field that contains inline fragments. The __typename of an object is what tells which
+// of the inline fragments its data is decoded into.
+func (f *field) addTypenames() {
+	var hasFragment, hasTypename bool
+	for i := range f.Fields {
+		f.Fields[i].addTypenames()
+
+		switch {
+		case f.Fields[i].isFragment() && !f.Fields[i].isSpread():
+			hasFragment = true
+		case f.Fields[i].Decl.Name == typenameField && f.Fields[i].Decl.Alias == "":
+			hasTypename = true
+		}
+	}
+
+	if hasFragment && !hasTypename {
+		f.Fields = append(f.Fields, field{
+			Decl: declaration{Name: typenameField},
+			Keep: true,
+		})
+	}
+}
+
+// ownTokens gathers the tokens contained within the declaration and directives of a
+// field, leaving out those of it's children fields.
+func (f *field) ownTokens() []token {
+	tokens := f.Decl.Arguments.tokens()
 	for _, directive := range f.Directives {
 		if (directive.Token != token{}) {
 			tokens = append(tokens, directive.Token)
 		}
+		tokens = append(tokens, directive.Arguments.tokens()...)
 	}
 
+	return tokens
+}
+
+// tokens recurses through a field to gather all tokens contained within the root
+// field as well as all of it's children fields.
+func (f *field) tokens() []token {
+	// Get the tokens from the declaration and directives of the current token.
+	tokens := f.ownTokens()
+
 	// Recurse through children tokens.
 	for i := range f.Fields {
 		tokens = append(tokens, f.Fields[i].tokens()...)
@@ -146,13 +220,13 @@ func (f *field) tokens() []token {
 }
 
 // argsFromTokens takes a slice of tokens, validates that there are not conflicting type
-// statements, and returns a slice of strings whose values are in the form of:
-// "$<arg>: <Type>" which can be joined by strings.Join(args, ", ") to render the correct
-// format to pass to either query(...) or mutation(...) at the top-level of a GraphQL
-// operation.
+// or default value statements, and returns a slice of strings whose values are in the form
+// of: "$<arg>: <Type>" or "$<arg>: <Type> = <Default>" which can be joined by
+// strings.Join(args, ", ") to render the correct format to pass to either query(...) or
+// mutation(...) at the top-level of a GraphQL operation.
 func argsFromTokens(tokens []token) ([]string, error) {
 	// len(tokens) might be too big, but it's at least the max size it could be.
-	argsMap := make(map[string]string, len(tokens))
+	argsMap := make(map[string]token, len(tokens))
 
 	// we want to ensure these args are always in the same ouput order as they were in the input
 	// order (first appearance wins). By having a sorted order of the keys, we achieve stable
@@ -161,30 +235,60 @@ func argsFromTokens(tokens []token) ([]string, error) {
 
 	// Make sure we don't duplicate variables if they're used more than once, and if
 	// they are used more than once, validate their types are the same.
+	// Variables that are used without a default value don't conflict with the ones that
+	// define it, the default value applies regardless.
 	for _, token := range tokens {
-		if kind, exists := argsMap[token.Arg]; exists {
-			if token.Kind != kind {
+		if existing, exists := argsMap[token.Arg]; exists {
+			if token.Kind != existing.Kind {
 				return nil, fmt.Errorf("argument $%s cannot have more than one type", token.Arg)
 			}
+
+			if token.Default != "" && existing.Default != "" && token.Default != existing.Default {
+				return nil, fmt.Errorf("argument $%s cannot have more than one default value", token.Arg)
+			}
+
+			if existing.Default == "" {
+				argsMap[token.Arg] = token
+			}
 			continue
 		}
 
-		argsMap[token.Arg] = token.Kind
+		argsMap[token.Arg] = token
 		argOrder = append(argOrder, token.Arg)
 	}
 
-	// This slice will contain values in the form of $<arg>: <Type> which can be joined
-	// with strings.Join(args, ", ") by the caller to achieve the correct format.
+	// This slice will contain values in the form of $<arg>: <Type> = <Default> which can be
+	// joined with strings.Join(args, ", ") by the caller to achieve the correct format.
 	args := make([]string, 0, len(argsMap))
 
 	for _, arg := range argOrder {
-		kind := argsMap[arg]
-		args = append(args, fmt.Sprintf("$%s: %s", arg, kind))
+		token := argsMap[arg]
+		if token.Default != "" {
+			args = append(args, fmt.Sprintf("$%s: %s = %s", arg, token.Kind, token.Default))
+			continue
+		}
+		args = append(args, fmt.Sprintf("$%s: %s", arg, token.Kind))
 	}
 
 	return args, nil
 }
 
+// selected returns the sparse fieldset information of the child field ff given the sparse
+// fieldset information of its parent.
+func selected(fields interface{}, ff *field) interface{} {
+	ts, ok := fields.(Fields)
+	if !ok {
+		return nil
+	}
+
+	// Fragments don't exist in the response, the fields within them are selected as if they
+	// were fields of the enclosing object.
+	if ff.isFragment() {
+		return ts
+	}
+	return ts[ff.Decl.Name]
+}
+
 // tokenizeWithFields recurses through a field to write all of the information
 // contained within the root field as well as all of it's children field to any
 // type that implements the io.Writer interface. Unlike tokenize method,
@@ -193,7 +297,7 @@ func argsFromTokens(tokens []token) ([]string, error) {
 // switched on.
 //
 // Returns a bool denoting whether or not the field was written and an error.
-func (f *field) tokenizeWithFields(w io.Writer, fields interface{}) (bool, error) { //nolint:funlen
+func (f *field) tokenizeWithFields(w *writer, fields interface{}) (bool, error) { //nolint:funlen
 	var write bool
 
 	switch ts := fields.(type) {
@@ -220,6 +324,23 @@ func (f *field) tokenizeWithFields(w io.Writer, fields interface{}) (bool, error
 		return false, nil
 	}
 
+	if f.isSpread() {
+		return f.tokenizeSpread(w, func(w *writer, ff *field) (bool, error) {
+			return ff.tokenizeWithFields(w, selected(fields, ff))
+		})
+	}
+
+	// Inline fragments without any fields selected within them are invalid, so they are
+	// rendered aside and only written if any of their fields were.
+	out := w
+	var fragment strings.Builder
+	if f.isFragment() {
+		w = w.to(&fragment)
+	}
+
+	var childWritten bool
+
+	mark := w.use(f)
 	f.Decl.tokenize(w)
 	for _, directive := range f.Directives {
 		io.WriteString(w, " ") //nolint:errcheck
@@ -230,42 +351,68 @@ func (f *field) tokenizeWithFields(w io.Writer, fields interface{}) (bool, error
 		io.WriteString(w, " {\n") //nolint:errcheck
 
 		for i := range f.Fields {
-			var written bool
-			var err error
-			ff := f.Fields[i]
-
-			switch ts := fields.(type) {
-			case Fields:
-				written, err = ff.tokenizeWithFields(w, ts[ff.Decl.Name])
-			default:
-				written, err = ff.tokenizeWithFields(w, nil)
-			}
-
+			written, err := f.Fields[i].tokenizeWithFields(w, selected(fields, &f.Fields[i]))
 			if err != nil {
 				return false, err
 			}
 
 			if written {
+				childWritten = true
 				io.WriteString(w, "\n") //nolint:errcheck
 			}
 		}
 		io.WriteString(w, "}") //nolint:errcheck
 	}
 
+	if f.isFragment() {
+		if !childWritten {
+			w.unuse(mark)
+			return false, nil
+		}
+		io.WriteString(out, fragment.String()) //nolint:errcheck
+	}
+
 	return write, nil
 }
 
 // tokenizeAsRoot skips tokenization for the declaration of the receiver field.
-// It writes the given declaration name to the writer interface and continues
-// the regular tokenization process for the field
-func (f *field) tokenizeAsRoot(w io.Writer, declName string, fields Fields) (bool, error) {
-	io.WriteString(w, declName) //nolint:errcheck
-	return f.tokenize(w, fields)
+// It writes the given wrapper ("query", "mutation", or "subscription") along with
+// the variables used by the fields that end up being rendered to the writer
+// interface and continues the regular tokenization process for the field
+func (f *field) tokenizeAsRoot(w *writer, wrapper string, fields Fields) (bool, error) {
+	// The variables are only known once the fields are rendered, so render them aside.
+	var body strings.Builder
+	written, err := f.tokenize(w.to(&body), fields)
+	if err != nil {
+		return false, err
+	}
+
+	args, err := argsFromTokens(w.variables)
+	if err != nil {
+		return false, err
+	}
+
+	// If there are arguments, add them to the root-level operation identifier
+	// within parenthesis.
+	io.WriteString(w, wrapper) //nolint:errcheck
+	if len(args) > 0 {
+		fmt.Fprintf(w, "(%s)", strings.Join(args, ", ")) //nolint:errcheck
+	}
+	io.WriteString(w, body.String()) //nolint:errcheck
+
+	return written, nil
 }
 
 // tokenizeAsLeaf tokenizes the declaration of the receiver field and continues
 // the regular tokenization process for the field
-func (f *field) tokenizeAsLeaf(w io.Writer, fields Fields) (bool, error) {
+func (f *field) tokenizeAsLeaf(w *writer, fields Fields) (bool, error) {
+	if f.isSpread() {
+		return f.tokenizeSpread(w, func(w *writer, ff *field) (bool, error) {
+			return ff.tokenizeAsLeaf(w, nil)
+		})
+	}
+
+	w.use(f)
 	f.Decl.tokenize(w)
 	return f.tokenize(w, fields)
 }
@@ -275,38 +422,47 @@ func (f *field) tokenizeAsLeaf(w io.Writer, fields Fields) (bool, error) {
 // implements the io.Writer interface.
 //
 // Returns a bool denoting whether or not the field was written and an error.
-func (f *field) tokenize(w io.Writer, fields Fields) (bool, error) { //nolint:gocyclo
+func (f *field) tokenize(w *writer, fields Fields) (bool, error) { //nolint:gocyclo
 	for _, directive := range f.Directives {
 		io.WriteString(w, " ") //nolint:errcheck
 		directive.tokenize(w)
 	}
 
-	var written bool
-	var err error
-
 	if len(f.Fields) > 0 {
 		io.WriteString(w, " {\n") //nolint:errcheck
+		if err := f.tokenizeChildren(w, fields); err != nil {
+			return false, err
+		}
+		io.WriteString(w, "}") //nolint:errcheck
+	}
 
-		for i := range f.Fields {
-			ff := f.Fields[i]
-			if fields == nil {
-				written, err = ff.tokenizeAsLeaf(w, nil)
-			} else {
-				written, err = ff.tokenizeWithFields(w, fields)
-			}
+	return true, nil
+}
 
-			if err != nil {
-				return false, err
-			}
+// tokenizeChildren writes the children fields of the receiver field, each followed by a newline,
+// to any type that implements the io.Writer interface.
+func (f *field) tokenizeChildren(w *writer, fields Fields) error {
+	var written bool
+	var err error
 
-			if written {
-				io.WriteString(w, "\n") //nolint:errcheck
-			}
+	for i := range f.Fields {
+		ff := f.Fields[i]
+		if fields == nil {
+			written, err = ff.tokenizeAsLeaf(w, nil)
+		} else {
+			written, err = ff.tokenizeWithFields(w, fields)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if written {
+			io.WriteString(w, "\n") //nolint:errcheck
 		}
-		io.WriteString(w, "}") //nolint:errcheck
 	}
 
-	return true, nil
+	return nil
 }
 
 // splitTag takes a tag and splits it into directives and declarations.
@@ -314,17 +470,32 @@ func splitTag(tag string) []string {
 	var sb strings.Builder
 	var split []string
 
-	var inArgs bool
+	// depth keeps track of how deeply nested within argument lists, list values, and object
+	// values the current rune is, inString whether or not it is within a string value.
+	var depth int
+	var inString, escaped bool
 	for _, r := range tag {
-		// This will allow us to ignore commas inside of argument lists.
-		if strings.ContainsRune("()", r) {
-			inArgs = !inArgs
-		}
-
-		// If we encounter a comma and we're not inside an argument list,
-		// add the current split value and reset the string builder to
-		// start to gather the next.
-		if r == ',' && !inArgs {
+		switch {
+		case inString:
+			// Skip over anything within strings, minding escaped quotes.
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				inString = false
+			}
+		case r == '"':
+			inString = true
+		case strings.ContainsRune("([{", r):
+			depth++
+		case strings.ContainsRune(")]}", r):
+			depth--
+		case r == ',' && depth == 0:
+			// If we encounter a comma and we're not inside an argument list,
+			// add the current split value and reset the string builder to
+			// start to gather the next.
 			split = append(split, sb.String())
 			sb.Reset()
 			continue
@@ -346,7 +517,7 @@ type tagParser func(reflect.StructTag) (field, error)
 // and directives.
 func parseTag(tag reflect.StructTag) (field, error) { //nolint:funlen
 	var f field
-	var alias string
+	var alias, typeCondition string
 
 	goqlTag := tag.Get(structTag)
 
@@ -365,8 +536,14 @@ func parseTag(tag reflect.StructTag) (field, error) { //nolint:funlen
 			// because reName matches the string "keep". This might be a problem?
 			f.Decl = declaration{Name: item}
 		case reDecl.MatchString(item):
-			f.Decl = parseDecl(item)
-			f.Keep = true
+			decl, err := parseDecl(item)
+			if err != nil {
+				return field{}, fmt.Errorf("invalid declaration %q in tag %q: %w", item, goqlTag, err)
+			}
+
+			f.Decl = decl
+		case reFragment.MatchString(item):
+			typeCondition = reFragment.FindStringSubmatch(item)[reFragmentType]
 		case reDirective.MatchString(item):
 			dir, err := parseDirective(item)
 			if err != nil {
@@ -387,6 +564,11 @@ func parseTag(tag reflect.StructTag) (field, error) { //nolint:funlen
 	}
 
 	f.Decl.Alias = alias
+	f.Decl.TypeCondition = typeCondition
+
+	if f.isFragment() && (f.Decl.Name != "" || f.Decl.Alias != "") {
+		return field{}, fmt.Errorf("inline fragment cannot be named or aliased in tag %q", goqlTag)
+	}
 
 	// sort directives to check for duplication
 	sort.Slice(f.Directives, func(i, j int) bool {
@@ -425,34 +607,27 @@ func parseTagSupportingJSON(tag reflect.StructTag) (field, error) {
 
 // parseDecl takes a declaration retrieved from a graphql struct tag and parses it
 // into a Declaration.
-func parseDecl(s string) declaration {
-	var tokens []token
-
-	matches := reDecl.FindStringSubmatch(s)
-	name := matches[reDeclName]
-
-	params := strings.Trim(matches[reDeclArgs], "()")
-	paramMatches := reParam.FindAllStringSubmatch(params, -1)
-	for _, match := range paramMatches {
-		tokens = append(tokens, token{
-			Kind: match[reParamKind],
-			Name: match[reParamName],
-			Arg:  match[reParamArg],
-		})
+func parseDecl(s string) (declaration, error) {
+	sc := scanner{src: s}
+
+	name, err := sc.name()
+	if err != nil {
+		return declaration{}, err
 	}
 
-	template := reParam.ReplaceAllStringFunc(params, func(param string) string {
-		if i := strings.Index(param, "["); i != -1 {
-			return param[:i]
-		}
-		return param
-	})
+	args, err := sc.arguments()
+	if err != nil {
+		return declaration{}, err
+	}
 
-	return declaration{
-		Name:     name,
-		Tokens:   tokens,
-		Template: template,
+	if sc.skipSpace(); !sc.eof() {
+		return declaration{}, sc.errorf("unexpected %q", sc.src[sc.pos:])
 	}
+
+	return declaration{
+		Name:      name,
+		Arguments: args,
+	}, nil
 }
 
 // parseDirective takes a declaration retrieved from a graphql struct tag and parses it
@@ -461,23 +636,41 @@ func parseDirective(s string) (directive, error) {
 	matches := reDirective.FindStringSubmatch(s)
 
 	dir := directive{
-		Type:     directiveEnum(matches[reDirectiveName]),
-		Template: strings.Trim(matches[reDirectiveArg], "()"),
+		Type: directiveEnum(matches[reDirectiveName]),
 	}
 
 	switch dir.Type {
-	case directiveAlias:
+	case directiveAlias, directiveInclude, directiveSkip:
+		if !reDirectiveShorthand.MatchString(matches[reDirectiveArg]) {
+			return directive{}, fmt.Errorf("directive %q in tag takes a single name or variable, got %q", dir.Type, s)
+		}
+		dir.Template = strings.Trim(matches[reDirectiveArg], "()")
+
 		// there can't be variables in aliases (they're technically not a directive,
 		// it's just easiest to deal with them as if they were one).
-	case directiveInclude, directiveSkip:
-		if strings.HasPrefix(dir.Template, "$") {
+		if dir.Type != directiveAlias && strings.HasPrefix(dir.Template, "$") {
 			dir.Token = token{
 				Kind: "Boolean!",
 				Arg:  dir.Template[1:],
 			}
 		}
 	default:
-		return directive{}, fmt.Errorf("unknown directive in tag \"%s\"", dir.Type)
+		// Any other directive is passed along as is, along with its arguments if it has any.
+		if matches[reDirectiveArg] == "" {
+			break
+		}
+
+		sc := scanner{src: matches[reDirectiveArg]}
+
+		args, err := sc.arguments()
+		if err != nil {
+			return directive{}, fmt.Errorf("invalid directive %q in tag: %w", s, err)
+		}
+
+		if sc.skipSpace(); !sc.eof() {
+			return directive{}, fmt.Errorf("invalid directive %q in tag: %w", s, sc.errorf("unexpected %q", sc.src[sc.pos:]))
+		}
+		dir.Arguments = args
 	}
 
 	return dir, nil
@@ -488,6 +681,13 @@ type node struct {
 	Name string
 	Type reflect.Type
 	Tag  reflect.StructTag
+
+	// Declared is the type that a struct field was declared with, Type is the same type with
+	// any pointers and slices dereferenced. It's nil for nodes that aren't struct fields.
+	Declared reflect.Type
+
+	// Anonymous denotes whether or not the node is an embedded struct field.
+	Anonymous bool
 }
 
 // visit defines a function signature used when "visiting" each node in a tree
@@ -521,9 +721,11 @@ func listFields(st reflect.Type) []node {
 		}
 
 		fields = append(fields, node{
-			Name: field.Name,
-			Type: deref(field.Type),
-			Tag:  field.Tag,
+			Name:      field.Name,
+			Type:      deref(field.Type),
+			Tag:       field.Tag,
+			Declared:  field.Type,
+			Anonymous: field.Anonymous,
 		})
 	}
 	return fields
@@ -596,6 +798,9 @@ func deref(t reflect.Type) reflect.Type {
 // optstruct holds onto state useful when applying options
 type optStruct struct {
 	tp tagParser
+
+	// format formats the rendered document, which is left as is if it's nil.
+	format func(string) (string, error)
 }
 
 // marshalOption is the type for our functional option for the marshal functions of GoQL. It's
@@ -620,6 +825,24 @@ func OptFallbackJSONTag(opt *optStruct) {
 	opt.tp = parseTagSupportingJSON
 }
 
+// OptPrettyPrint causes the marshalling of structs to render operations with every selection
+// indented by two spaces per level of nesting and the definitions of named fragments separated
+// by blank lines, the same way WriteQueryDocument does, which is meant for debugging and golden
+// files rather than for transport. By default operations are rendered with a selection per line
+// and no indentation.
+func OptPrettyPrint(opt *optStruct) {
+	opt.format = prettyPrint
+}
+
+// OptMinify causes the marshalling of structs to render operations without any insignificant
+// whitespace, e.g. query($id:ID!){user(id:$id){id name}}, which makes for smaller requests. By
+// default operations are rendered with a selection per line and no indentation.
+func OptMinify(opt *optStruct) {
+	opt.format = func(doc string) (string, error) {
+		return minify(doc), nil
+	}
+}
+
 // MarshalQuery takes a variable that must be a struct type and constructs a GraphQL
 // operation using it's fields and graphql struct tags that can be used as a GraphQL
 // query operation.
@@ -634,6 +857,13 @@ func MarshalMutation(q interface{}, fields Fields) (string, error) {
 	return MarshalMutationWithOptions(q, fields, OptGoqlTagsOnly)
 }
 
+// MarshalSubscription takes a variable that must be a struct type and constructs a GraphQL
+// operation using it's fields and graphql struct tags that can be used as a GraphQL
+// subscription operation.
+func MarshalSubscription(q interface{}, fields Fields) (string, error) {
+	return MarshalSubscriptionWithOptions(q, fields, OptGoqlTagsOnly)
+}
+
 // MarshalQueryWithOptions takes a variable that must be a struct type and constructs a GraphQL
 // operation using it's fields and graphql struct tags that can be used as a GraphQL query
 // operation. Additionally, MarshalQueryWithOptions accepts an array of functional options to
@@ -648,7 +878,7 @@ func MarshalQueryWithOptions(q interface{}, fields Fields, opts ...marshalOption
 			opt(&o)
 		}
 	}
-	return marshal(q, "query", fields, o.tp)
+	return marshal(q, "query", fields, o)
 }
 
 // MarshalMutationWithOptions takes a variable that must be a struct type and constructs a GraphQL
@@ -665,18 +895,122 @@ func MarshalMutationWithOptions(q interface{}, fields Fields, opts ...marshalOpt
 			opt(&o)
 		}
 	}
-	return marshal(q, "mutation", fields, o.tp)
+	return marshal(q, "mutation", fields, o)
+}
+
+// MarshalSubscriptionWithOptions takes a variable that must be a struct type and constructs a
+// GraphQL operation using it's fields and graphql struct tags that can be used as a GraphQL
+// subscription operation. Additionally, MarshalSubscriptionWithOptions accepts an array of
+// functional options to change the marshalling behavior.
+func MarshalSubscriptionWithOptions(q interface{}, fields Fields, opts ...marshalOption) (string, error) {
+	o := optStruct{}
+	// by putting OptGoqlTagsOnly at the front, we ensure it'll be overridden by subsequent
+	// user-provided options
+	opts = append([]marshalOption{OptGoqlTagsOnly}, opts...)
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return marshal(q, "subscription", fields, o)
 }
 
 // cache stores the resulting tree of types who have already been through the marshaling
 // process.
 var cache sync.Map
 
+// hashes stores the hashes of the documents that have already been through the marshaling
+// process, keyed by the document, as *documentDigest. Like the trees in cache, there's one per
+// type, set of fields, and options that operations are marshaled with. Their hashes are only
+// computed once they're needed, e.g. when using persisted queries.
+var hashes sync.Map
+
+// documentDigest is the lazily computed hash of a document.
+type documentDigest struct {
+	once sync.Once
+	hash string
+}
+
+// documentHash returns the hex encoded SHA-256 hash of the query, which is only computed once
+// for documents that were marshaled by this package.
+func documentHash(query string) string {
+	if v, hit := hashes.Load(query); hit {
+		digest := v.(*documentDigest)
+		digest.once.Do(func() {
+			digest.hash = sha256Hex(query)
+		})
+		return digest.hash
+	}
+
+	return sha256Hex(query)
+}
+
+// sha256Hex returns the hex encoded SHA-256 hash of s.
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
 // marshal takes a variable that must be a struct type and constructs a GraphQL operation
 // using it's fields and graphql struct tags. The wrapper variable defines what type of
-// GraphQL operation will be returned ("query" or "mutation", although this is not
-// explicitly checked since this function is only called from within this package).
-func marshal(q interface{}, wrapper string, fields Fields, tagParse tagParser) (string, error) { //nolint:funlen
+// GraphQL operation will be returned ("query", "mutation", or "subscription", although this
+// is not explicitly checked since this function is only called from within this package).
+// The document is formatted by the format of o, e.g. pretty-printed, if it has one.
+func marshal(q interface{}, wrapper string, fields Fields, o optStruct) (string, error) {
+	operation, err := build(q, o.tp)
+	if err != nil {
+		return "", err
+	}
+
+	// Validate the tokens contained in operation and it's children, regardless of which of
+	// them end up being rendered.
+	if _, err := argsFromTokens(operation.tokens()); err != nil {
+		return "", err
+	}
+
+	// Named operations are rendered as e.g. "query GetUser(...)" rather than "query(...)".
+	name, err := operationName(q)
+	if err != nil {
+		return "", err
+	}
+
+	if name != "" {
+		wrapper = fmt.Sprintf("%s %s", wrapper, name)
+	}
+
+	var b strings.Builder
+	w := writer{Writer: &b, document: &document{}}
+
+	// Construct the actual operation from the fields gathered while walking through q's nodes.
+	// The top-level declaration will be the name of the struct (q), we don't need that. We
+	// need either "query", "mutation", or "subscription" at the root-level of the operation.
+	if _, err := operation.tokenizeAsRoot(&w, wrapper, fields); err != nil {
+		return "", err
+	}
+
+	// Append the definitions of the named fragments that were spread throughout the operation.
+	w.tokenizeDefinitions(&b)
+
+	doc := b.String()
+	if o.format != nil {
+		if doc, err = o.format(doc); err != nil {
+			return "", err
+		}
+	}
+
+	// Keep track of the document for its hash to be computed at most once, the same document
+	// is marshaled over and over again for any given type and set of fields.
+	if _, hit := hashes.Load(doc); !hit {
+		hashes.LoadOrStore(doc, &documentDigest{})
+	}
+
+	return doc, nil
+}
+
+// build returns the tree of fields of the operation q, which is built by walking through the
+// type of q the first time it is seen and taken from the cache after that. The tree is shared
+// between every use of the type of q, so it must not be modified.
+func build(q interface{}, tagParse tagParser) (*field, error) { //nolint:funlen
 	var operation *field
 	rt := reflect.TypeOf(q)
 
@@ -689,6 +1023,11 @@ func marshal(q interface{}, wrapper string, fields Fields, tagParse tagParser) (
 		// cache for later use.
 		var st stack
 
+		// spreads runs parallel to st and holds onto the spread of the named fragment that the
+		// children of the corresponding field need to be moved into once they're all known,
+		// or an empty declaration if there is none.
+		var spreads []declaration
+
 		// The visit func that gets passed to Walk handles the stack management while walking
 		// through the root node and all of it's children to create the declarations, directives,
 		// and their tokens which are used to create the GraphQL operation.
@@ -701,10 +1040,39 @@ func marshal(q interface{}, wrapper string, fields Fields, tagParse tagParser) (
 					return err
 				}
 
-				if f.Decl.Name == "" {
+				if f.isFragment() && n.Type.Kind() != reflect.Struct {
+					return fmt.Errorf("inline fragment on %s must be a struct, got %s", f.Decl.TypeCondition, n.Type.Kind())
+				}
+
+				var spread declaration
+				if st.length() > 0 {
+					name, on, ok, err := namedFragment(n.Type)
+					if err != nil {
+						return err
+					}
+
+					if ok {
+						spread = declaration{Fragment: name, TypeCondition: on}
+					}
+				}
+
+				// Embedded named fragments without a name of their own are spread in place,
+				// any other field of a named fragment type selects a spread of it.
+				if spread.Fragment != "" && n.Anonymous && f.Decl.Name == "" && f.Decl.Alias == "" && !f.isFragment() {
+					f.Decl, spread = spread, declaration{}
+				}
+
+				if f.Decl.Name == "" && !f.isFragment() {
 					f.Decl.Name = toLowerCamelCase(n.Name)
 				}
+
+				// Top-level models with arguments are operation declarations, which are always
+				// kept.
+				if st.length() == 1 && len(f.Decl.Arguments) > 0 {
+					f.Keep = true
+				}
 				st.push(&f)
+				spreads = append(spreads, spread)
 			} else {
 				// don't pop the root node
 				if st.length() == 1 {
@@ -713,6 +1081,13 @@ func marshal(q interface{}, wrapper string, fields Fields, tagParse tagParser) (
 
 				// add most recent node to parent
 				nf := st.pop()
+				spread := spreads[len(spreads)-1]
+				spreads = spreads[:len(spreads)-1]
+
+				if spread.Fragment != "" {
+					nf.Fields = []field{{Decl: spread, Fields: nf.Fields}}
+				}
+
 				st.apply(func(f *field) {
 					f.Fields = append(f.Fields, *nf)
 				})
@@ -723,39 +1098,17 @@ func marshal(q interface{}, wrapper string, fields Fields, tagParse tagParser) (
 
 		// Walk through the given struct.
 		if err := walk(q, visitFn); err != nil {
-			return "", err
+			return nil, err
 		}
 
 		// The top of the stack at this point will be the top-level field with all of
 		// the inner fields as children.
 		operation = st.top()
+		operation.addTypenames()
 
 		// Store this built tree for the operation in the cache.
 		cache.Store(rt, operation)
 	}
 
-	// Get the args from the tokens contained in operation and it's children.
-	args, err := argsFromTokens(operation.tokens())
-	if err != nil {
-		return "", err
-	}
-
-	// The top-level declaration will be the name of the struct (q), we don't need that. We
-	// need either "query" or "mutation" at the root-level of the operation.
-	declName := wrapper
-
-	// If there are arguments, add them to the root-level "query" or "mutation" operation identifier
-	// within parenthesis.
-	if len(args) > 0 {
-		declName = fmt.Sprintf("%s(%s)", declName, strings.Join(args, ", "))
-	}
-
-	var b strings.Builder
-
-	// Construct the actual operation from the fields gathered while walking through q's nodes.
-	if _, err := operation.tokenizeAsRoot(&b, declName, fields); err != nil {
-		return "", err
-	}
-
-	return b.String(), nil
+	return operation, nil
 }
@@ -7,6 +7,48 @@ import (
 	"github.com/pmezard/go-difflib/difflib"
 )
 
+// TestUserSummary is a named fragment used throughout the marshaling tests.
+type TestUserSummary struct {
+	ID   string
+	Name string
+}
+
+// FragmentName implements the Fragment interface.
+func (TestUserSummary) FragmentName() string {
+	return "UserSummary"
+}
+
+// FragmentOn implements the Fragment interface.
+func (TestUserSummary) FragmentOn() string {
+	return "User"
+}
+
+// TestGetUser is a named operation used throughout the marshaling tests.
+type TestGetUser struct {
+	User struct {
+		ID   string
+		Name string
+	} `goql:"user(id:$id<ID!>)"`
+}
+
+// OperationName implements the NamedOperation interface.
+func (*TestGetUser) OperationName() string {
+	return "GetUser"
+}
+
+// TestInvalidName is a named operation with an invalid name used throughout the marshaling
+// tests.
+type TestInvalidName struct {
+	User struct {
+		ID string
+	}
+}
+
+// OperationName implements the NamedOperation interface.
+func (TestInvalidName) OperationName() string {
+	return "Get User"
+}
+
 // TestMarshalQuery tests the MarshalQuery function.
 func TestMarshalQuery(t *testing.T) {
 	tt := []struct {
@@ -64,6 +106,150 @@ fieldTwo
 }
 }`,
 		},
+		{
+			Name: "WithLiteralArguments",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string
+					FieldTwo string
+				} `goql:"testQuery(first:10,ratio:-1.5e3,name:\"Jane \\\"JD\\\" Doe, Jr.\",active:true,owner:null,order:DESC)"`
+			}{},
+			Fields: nil,
+			ExpectedOutput: `query {
+testQuery(first: 10, ratio: -1.5e3, name: "Jane \"JD\" Doe, Jr.", active: true, owner: null, order: DESC) {
+fieldOne
+fieldTwo
+}
+}`,
+		},
+		{
+			Name: "WithListAndObjectArguments",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string
+					FieldTwo string
+				} `goql:"testQuery(ids:[1, 2, 3],filter:{status:[ACTIVE],owner:{id:$id<ID!>}},sort:[{field:NAME}])"`
+			}{},
+			Fields: nil,
+			ExpectedOutput: `query($id: ID!) {
+testQuery(ids: [1, 2, 3], filter: {status: [ACTIVE], owner: {id: $id}}, sort: [{field: NAME}]) {
+fieldOne
+fieldTwo
+}
+}`,
+		},
+		{
+			Name: "ErrorUnterminatedStringArgument",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string
+				} `goql:"testQuery(name:\"Jane)"`
+			}{},
+			Fields:         nil,
+			ExpectedOutput: "",
+		},
+		{
+			Name: "ErrorUntypedVariableArgument",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string
+				} `goql:"testQuery(filter:{id:$id})"`
+			}{},
+			Fields:         nil,
+			ExpectedOutput: "",
+		},
+		{
+			Name: "ErrorUnbalancedArguments",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string
+				} `goql:"testQuery(ids:[1, 2)"`
+			}{},
+			Fields:         nil,
+			ExpectedOutput: "",
+		},
+		{
+			Name: "WithNestedListVariables",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string
+					FieldTwo string
+				} `goql:"testQuery(points:$points<[[Float!]!]>,grid:$grid<[[[Int]]]!>)"`
+			}{},
+			Fields: nil,
+			ExpectedOutput: `query($points: [[Float!]!], $grid: [[[Int]]]!) {
+testQuery(points: $points, grid: $grid) {
+fieldOne
+fieldTwo
+}
+}`,
+		},
+		{
+			Name: "WithVariableDefaults",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string
+					FieldTwo string `goql:"fieldTwo(size:$size<Int>=25,order:$order<[Order!]> = [{field: NAME}])"`
+				} `goql:"testQuery(id:$id<ID!>,size:$size<Int>)"`
+			}{},
+			Fields: nil,
+			ExpectedOutput: `query($id: ID!, $size: Int = 25, $order: [Order!] = [{field: NAME}]) {
+testQuery(id: $id, size: $size) {
+fieldOne
+fieldTwo(size: $size, order: $order)
+}
+}`,
+		},
+		{
+			Name: "ErrorConflictingVariableDefaults",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string `goql:"fieldOne(size:$size<Int>=10)"`
+					FieldTwo string
+				} `goql:"testQuery(size:$size<Int>=25)"`
+			}{},
+			Fields:         nil,
+			ExpectedOutput: "",
+		},
+		{
+			Name: "ErrorVariableInVariableDefault",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string
+					FieldTwo string
+				} `goql:"testQuery(size:$size<Int>=$other<Int>)"`
+			}{},
+			Fields:         nil,
+			ExpectedOutput: "",
+		},
+		{
+			Name:   "WithOperationName",
+			Input:  TestGetUser{},
+			Fields: nil,
+			ExpectedOutput: `query GetUser($id: ID!) {
+user(id: $id) {
+id
+name
+}
+}`,
+		},
+		{
+			Name:   "WithOperationNameByReference",
+			Input:  &TestGetUser{},
+			Fields: nil,
+			ExpectedOutput: `query GetUser($id: ID!) {
+user(id: $id) {
+id
+name
+}
+}`,
+		},
+		{
+			Name:           "ErrorInvalidOperationName",
+			Input:          TestInvalidName{},
+			Fields:         nil,
+			ExpectedOutput: "",
+		},
 		{
 			Name: "WithNameOverride",
 			Input: struct {
@@ -128,6 +314,54 @@ fieldTwo @include(if: $ifCondition)
 }
 }`,
 		},
+		{
+			Name: "WithCustomDirectives",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string `goql:"@cacheControl(maxAge:60,scope:PRIVATE)"`
+					FieldTwo string `goql:"@deprecatedAccess(reason:\"use, fieldOne\"),@include($withFieldTwo)"`
+					Comments []struct {
+						Body string
+					} `goql:"@stream(initialCount:$initialCount<Int>=2)"`
+					Author struct {
+						Name string
+					} `goql:"@defer"`
+				} `goql:"testQuery(id:$id<ID!>)"`
+			}{},
+			Fields: nil,
+			ExpectedOutput: `query($id: ID!, $withFieldTwo: Boolean!, $initialCount: Int = 2) {
+testQuery(id: $id) {
+fieldOne @cacheControl(maxAge: 60, scope: PRIVATE)
+fieldTwo @deprecatedAccess(reason: "use, fieldOne") @include(if: $withFieldTwo)
+comments @stream(initialCount: $initialCount) {
+body
+}
+author @defer {
+name
+}
+}
+}`,
+		},
+		{
+			Name: "ErrorInvalidCustomDirective",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string `goql:"@cacheControl(maxAge)"`
+				}
+			}{},
+			Fields:         nil,
+			ExpectedOutput: "",
+		},
+		{
+			Name: "ErrorIncludeDirectiveWithArguments",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string `goql:"@include(if:$withFieldOne<Boolean!>)"`
+				}
+			}{},
+			Fields:         nil,
+			ExpectedOutput: "",
+		},
 		{
 			Name: "SimpleWithSparseFieldset",
 			Input: struct {
@@ -293,6 +527,219 @@ fieldABC
 }
 }`,
 		},
+		{
+			Name: "WithNestedArgumentsAndSparseFieldset",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string
+					Avatar   string `goql:"avatar(size:$size<Int>)"`
+					Comments []struct {
+						Body string
+					} `goql:"comments(first:$n<Int>)"`
+				} `goql:"testQuery(id:$id<ID!>)"`
+			}{},
+			Fields: Fields{
+				"fieldOne": true,
+			},
+			ExpectedOutput: `query($id: ID!) {
+testQuery(id: $id) {
+fieldOne
+}
+}`,
+		},
+		{
+			Name: "WithNestedArgumentsAndSparseFieldsetIncludingThem",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string
+					Avatar   string `goql:"avatar(size:$size<Int>)"`
+					Comments []struct {
+						Body string
+					} `goql:"comments(first:$n<Int>)"`
+				} `goql:"testQuery(id:$id<ID!>)"`
+			}{},
+			Fields: Fields{
+				"fieldOne": true,
+				"comments": Fields{
+					"body": true,
+				},
+			},
+			ExpectedOutput: `query($id: ID!, $n: Int) {
+testQuery(id: $id) {
+fieldOne
+comments(first: $n) {
+body
+}
+}
+}`,
+		},
+		{
+			Name: "ErrorConflictingTypesOfUnselectedVariable",
+			Input: struct {
+				TestQuery struct {
+					FieldOne string
+					Avatar   string `goql:"avatar(size:$id<Int>)"`
+				} `goql:"testQuery(id:$id<ID!>)"`
+			}{},
+			Fields: Fields{
+				"fieldOne": true,
+			},
+			ExpectedOutput: "",
+		},
+		{
+			Name: "WithInlineFragments",
+			Input: struct {
+				TestQuery struct {
+					ID   string
+					User struct {
+						Name string
+					} `goql:"... on User"`
+					Team *struct {
+						Size int
+					} `goql:"... on Team,@include($withTeam)"`
+				} `goql:"testQuery(id:$id<ID!>)"`
+			}{},
+			Fields: nil,
+			ExpectedOutput: `query($id: ID!, $withTeam: Boolean!) {
+testQuery(id: $id) {
+id
+... on User {
+name
+}
+... on Team @include(if: $withTeam) {
+size
+}
+__typename
+}
+}`,
+		},
+		{
+			Name: "WithInlineFragmentsAndSparseFieldset",
+			Input: struct {
+				TestQuery struct {
+					ID   string
+					User struct {
+						Name  string
+						Email string
+					} `goql:"... on User"`
+					Team struct {
+						Size int
+					} `goql:"... on Team"`
+				} `goql:"testQuery(id:$id<ID!>)"`
+			}{},
+			Fields: Fields{
+				"id":    true,
+				"email": true,
+			},
+			ExpectedOutput: `query($id: ID!) {
+testQuery(id: $id) {
+id
+... on User {
+email
+}
+__typename
+}
+}`,
+		},
+		{
+			Name: "WithNamedFragments",
+			Input: struct {
+				TestQuery struct {
+					Author TestUserSummary
+					Editor *TestUserSummary `goql:"@include($withEditor)"`
+					Viewer struct {
+						TestUserSummary
+						Email string
+					}
+				} `goql:"testQuery(id:$id<ID!>)"`
+			}{},
+			Fields: nil,
+			ExpectedOutput: `query($id: ID!, $withEditor: Boolean!) {
+testQuery(id: $id) {
+author {
+...UserSummary
+}
+editor @include(if: $withEditor) {
+...UserSummary
+}
+viewer {
+...UserSummary
+email
+}
+}
+}
+fragment UserSummary on User {
+id
+name
+}`,
+		},
+		{
+			Name: "WithNamedFragmentsAndSparseFieldset",
+			Input: struct {
+				TestQuery struct {
+					Author TestUserSummary
+					Editor TestUserSummary
+					Viewer struct {
+						TestUserSummary
+						Email string
+					}
+				} `goql:"testQuery(id:$id<ID!>)"`
+			}{},
+			Fields: Fields{
+				"author": Fields{
+					"id": true,
+				},
+				"editor": Fields{
+					"name": true,
+				},
+				"viewer": Fields{
+					"id":    true,
+					"email": true,
+				},
+			},
+			ExpectedOutput: `query($id: ID!) {
+testQuery(id: $id) {
+author {
+...UserSummary
+}
+editor {
+...UserSummary_2
+}
+viewer {
+...UserSummary
+email
+}
+}
+}
+fragment UserSummary on User {
+id
+}
+fragment UserSummary_2 on User {
+name
+}`,
+		},
+		{
+			Name: "ErrorNamedInlineFragment",
+			Input: struct {
+				TestQuery struct {
+					User struct {
+						Name string
+					} `goql:"user,... on User"`
+				}
+			}{},
+			Fields:         nil,
+			ExpectedOutput: "",
+		},
+		{
+			Name: "ErrorNonStructInlineFragment",
+			Input: struct {
+				TestQuery struct {
+					Name string `goql:"... on User"`
+				}
+			}{},
+			Fields:         nil,
+			ExpectedOutput: "",
+		},
 		{
 			Name: "SimpleWithJSON",
 			Input: struct {
@@ -698,3 +1145,67 @@ overrideName
 		t.Run(test.Name, fn)
 	}
 }
+
+// TestMarshalSubscription tests the MarshalSubscription function.
+func TestMarshalSubscription(t *testing.T) {
+	tt := []struct {
+		Name           string
+		Input          interface{}
+		Fields         Fields
+		ExpectedOutput string // IfExpectedOutput == "", it implies an error.
+	}{
+		{
+			Name: "Simple",
+			Input: struct {
+				TestSubscription struct {
+					FieldOne string
+					FieldTwo string
+				}
+			}{},
+			Fields: nil,
+			ExpectedOutput: `subscription {
+testSubscription {
+fieldOne
+fieldTwo
+}
+}`,
+		},
+		{
+			Name: "WithVariables",
+			Input: struct {
+				TestSubscription struct {
+					FieldOne string
+					FieldTwo string
+				} `goql:"testSubscription(id:$id<ID!>)"`
+			}{},
+			Fields: nil,
+			ExpectedOutput: `subscription($id: ID!) {
+testSubscription(id: $id) {
+fieldOne
+fieldTwo
+}
+}`,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			actualOutput, err := MarshalSubscription(test.Input, test.Fields)
+			if err != nil {
+				if test.ExpectedOutput == "" {
+					// The error was expected, return without reporting anything.
+					return
+				}
+
+				t.Fatalf("error marshaling subscription: %v", err)
+			}
+
+			if e, a := strings.TrimSpace(test.ExpectedOutput), strings.TrimSpace(actualOutput); e != a {
+				t.Fatalf("expected output to be:\n%s\ngot:\n%s", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
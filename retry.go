@@ -0,0 +1,177 @@
+package goql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// Defaults of the RetryPolicy type.
+const (
+	// defaultInitialBackoff is the default of RetryPolicy.InitialBackoff.
+	defaultInitialBackoff = 100 * time.Millisecond
+
+	// defaultMaxBackoff is the default of RetryPolicy.MaxBackoff.
+	defaultMaxBackoff = 5 * time.Second
+
+	// defaultMaxRetryAfter is the default of RetryPolicy.MaxRetryAfter.
+	defaultMaxRetryAfter = time.Minute
+)
+
+// defaultRetryableStatusCodes is the default of RetryPolicy.RetryableStatusCodes.
+var defaultRetryableStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy configures how the Client retries requests that failed in a way that is likely
+// to be transient, which are requests that failed due to a network error and requests whose
+// response has a retryable status code. The zero value of RetryPolicy disables retries.
+//
+// Queries are retried by default, since they are idempotent. Mutations are only retried if
+// RetryMutations is true. Operations performed through CustomOperation are treated as
+// mutations if the query starts with "mutation" and as queries otherwise.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is attempted, including the first
+	// attempt. Values of 1 or less disable retries.
+	MaxAttempts int
+
+	// InitialBackoff is how long to wait before the first retry, it doubles with every
+	// subsequent retry up to MaxBackoff. A random jitter of up to half of the backoff is
+	// subtracted from it to spread out the retries of concurrent requests. Defaults to 100ms.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum time to wait between attempts. Defaults to 5s. It doesn't apply
+	// to the time asked for by the Retry-After header of a response, see MaxRetryAfter.
+	MaxBackoff time.Duration
+
+	// MaxRetryAfter is the longest time asked for by the Retry-After header of a response that
+	// is honored. Requests whose response asks for a longer wait aren't retried, the response
+	// is returned as is instead. Defaults to 1m.
+	MaxRetryAfter time.Duration
+
+	// RetryableStatusCodes are the status codes of responses that are retried. Defaults to
+	// 408, 429, 502, 503, and 504.
+	RetryableStatusCodes []int
+
+	// RetryMutations, if true, makes mutations get retried just like queries. Only set this
+	// if the mutations performed through the client are idempotent.
+	RetryMutations bool
+}
+
+// idempotent denotes whether or not the operation of req is retried.
+func (p *RetryPolicy) idempotent(req *Request) bool {
+	return req.operationType() != "mutation" || p.RetryMutations
+}
+
+// retry denotes whether or not the given attempt, which resulted in either resp or err, of a
+// request should be retried and if so, how long to wait before doing so.
+func (p *RetryPolicy) retry(ctx context.Context, attempt int, idempotent bool, resp *Response, err error) (time.Duration, bool) {
+	if !idempotent || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return 0, false
+	}
+
+	var header http.Header
+	switch {
+	case resp != nil:
+		if !p.retryableStatus(resp.StatusCode) {
+			return 0, false
+		}
+		header = resp.Header
+	case err != nil:
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			if !p.retryableStatus(httpErr.StatusCode) {
+				return 0, false
+			}
+			header = httpErr.Header
+		} else if !retryableError(err) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	if wait, ok := retryAfter(header, time.Now()); ok {
+		maximum := p.MaxRetryAfter
+		if maximum <= 0 {
+			maximum = defaultMaxRetryAfter
+		}
+		return wait, wait <= maximum
+	}
+	return p.backoff(attempt), true
+}
+
+// retryableStatus denotes whether or not responses with the given status code are retried.
+func (p *RetryPolicy) retryableStatus(statusCode int) bool {
+	statusCodes := p.RetryableStatusCodes
+	if statusCodes == nil {
+		statusCodes = defaultRetryableStatusCodes
+	}
+	return slices.Contains(statusCodes, statusCode)
+}
+
+// backoff returns how long to wait after the given attempt, using exponential backoff with
+// jitter.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	initial, maximum := p.InitialBackoff, p.MaxBackoff
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+	if maximum <= 0 {
+		maximum = defaultMaxBackoff
+	}
+
+	backoff := initial
+	for i := 1; i < attempt && backoff < maximum; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, maximum)
+
+	return backoff - rand.N(backoff/2+1) //nolint:gosec // Why: jitter doesn't need to be secure
+}
+
+// retryableError denotes whether or not err, returned from performing a request, is a network
+// error that is likely to be transient.
+func retryableError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// Every error returned from http.Client.Do is a *url.Error, which implements net.Error
+	// itself, so look at what it wraps instead.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// sleep waits for d to pass, returning early with the error of ctx if it is done before then.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,256 @@
+package goql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRetry tests that the client retries requests according to its retry policy.
+func TestRetry(t *testing.T) {
+	tt := []struct {
+		Name             string
+		Query            string
+		Policy           RetryPolicy
+		Failures         int32
+		FailureStatus    int
+		ExpectedAttempts int32
+		ShouldErr        bool
+	}{
+		{
+			Name:             "QueryRecovers",
+			Query:            "query { foo }",
+			Policy:           RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			Failures:         2,
+			FailureStatus:    http.StatusServiceUnavailable,
+			ExpectedAttempts: 3,
+			ShouldErr:        false,
+		},
+		{
+			Name:             "QueryExhaustsAttempts",
+			Query:            "query { foo }",
+			Policy:           RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			Failures:         5,
+			FailureStatus:    http.StatusBadGateway,
+			ExpectedAttempts: 3,
+			ShouldErr:        true,
+		},
+		{
+			Name:             "QueryNonRetryableStatus",
+			Query:            "query { foo }",
+			Policy:           RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			Failures:         1,
+			FailureStatus:    http.StatusInternalServerError,
+			ExpectedAttempts: 1,
+			ShouldErr:        true,
+		},
+		{
+			Name:  "QueryCustomRetryableStatus",
+			Query: "query { foo }",
+			Policy: RetryPolicy{
+				MaxAttempts:          3,
+				InitialBackoff:       time.Millisecond,
+				RetryableStatusCodes: []int{http.StatusInternalServerError},
+			},
+			Failures:         1,
+			FailureStatus:    http.StatusInternalServerError,
+			ExpectedAttempts: 2,
+			ShouldErr:        false,
+		},
+		{
+			Name:             "MutationNotRetried",
+			Query:            "mutation { foo }",
+			Policy:           RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			Failures:         1,
+			FailureStatus:    http.StatusServiceUnavailable,
+			ExpectedAttempts: 1,
+			ShouldErr:        true,
+		},
+		{
+			Name:             "MutationAfterCommentNotRetried",
+			Query:            "# Creates a foo.\nmutation { foo }",
+			Policy:           RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			Failures:         1,
+			FailureStatus:    http.StatusServiceUnavailable,
+			ExpectedAttempts: 1,
+			ShouldErr:        true,
+		},
+		{
+			Name:             "MutationAfterFragmentNotRetried",
+			Query:            "fragment F on Foo { id } mutation { foo { ...F } }",
+			Policy:           RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			Failures:         1,
+			FailureStatus:    http.StatusServiceUnavailable,
+			ExpectedAttempts: 1,
+			ShouldErr:        true,
+		},
+		{
+			Name:             "MutationRetried",
+			Query:            "mutation { foo }",
+			Policy:           RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, RetryMutations: true},
+			Failures:         1,
+			FailureStatus:    http.StatusServiceUnavailable,
+			ExpectedAttempts: 2,
+			ShouldErr:        false,
+		},
+		{
+			Name:             "Disabled",
+			Query:            "query { foo }",
+			Policy:           RetryPolicy{},
+			Failures:         1,
+			FailureStatus:    http.StatusServiceUnavailable,
+			ExpectedAttempts: 1,
+			ShouldErr:        true,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			var attempts atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if attempts.Add(1) <= test.Failures {
+					w.WriteHeader(test.FailureStatus)
+					return
+				}
+				io.WriteString(w, `{"data":{"foo":"bar"}}`) //nolint:errcheck
+			}))
+			t.Cleanup(ts.Close)
+
+			client := NewClient(ts.URL, ClientOptions{
+				RetryPolicy: test.Policy,
+			})
+
+			var resp struct {
+				Foo string `json:"foo"`
+			}
+
+			err := client.CustomOperation(context.Background(), test.Query, nil, &resp)
+			if test.ShouldErr != (err != nil) {
+				t.Errorf("expected error to be returned to be %t, got %v", test.ShouldErr, err)
+			}
+
+			if e, a := test.ExpectedAttempts, attempts.Load(); e != a {
+				t.Errorf("expected %d attempts, got %d", e, a)
+			}
+
+			if !test.ShouldErr && resp.Foo != "bar" {
+				t.Errorf("expected response to be decoded, got %+v", resp)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
+
+// TestRetryPolicyRetry tests the retry receiver function on the RetryPolicy type.
+func TestRetryPolicyRetry(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Second}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rateLimited := &HTTPError{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"42"}}}
+	unavailable := &Response{StatusCode: http.StatusServiceUnavailable}
+
+	if wait, retry := policy.retry(context.Background(), 1, true, nil, rateLimited); !retry || wait != 42*time.Second {
+		t.Errorf("expected Retry-After to be honored, got %s (%t)", wait, retry)
+	}
+
+	rateLimitedForADay := &HTTPError{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"86400"}}}
+	if _, retry := policy.retry(context.Background(), 1, true, nil, rateLimitedForADay); retry {
+		t.Error("expected no retry when Retry-After asks for longer than MaxRetryAfter")
+	}
+
+	if wait, retry := policy.retry(context.Background(), 1, true, unavailable, nil); !retry || wait < time.Second/2 || wait > time.Second {
+		t.Errorf("expected backoff of between 500ms and 1s, got %s (%t)", wait, retry)
+	}
+
+	if _, retry := policy.retry(context.Background(), 3, true, unavailable, nil); retry {
+		t.Error("expected no retry after the last attempt")
+	}
+
+	if _, retry := policy.retry(context.Background(), 1, false, unavailable, nil); retry {
+		t.Error("expected no retry of a non-idempotent request")
+	}
+
+	if _, retry := policy.retry(canceled, 1, true, unavailable, nil); retry {
+		t.Error("expected no retry once the context is done")
+	}
+
+	if _, retry := policy.retry(context.Background(), 1, true, &Response{StatusCode: http.StatusOK}, nil); retry {
+		t.Error("expected no retry of a successful response")
+	}
+}
+
+// TestRetryPolicyBackoff tests the backoff receiver function on the RetryPolicy type.
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for attempt, maximum := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		for i := 0; i < 100; i++ {
+			if backoff := policy.backoff(attempt); backoff < maximum/2 || backoff > maximum {
+				t.Fatalf("expected backoff of attempt %d to be between %s and %s, got %s", attempt, maximum/2, maximum, backoff)
+			}
+		}
+	}
+}
+
+// TestRetryableError tests the retryableError function.
+func TestRetryableError(t *testing.T) {
+	tt := []struct {
+		Name           string
+		Input          error
+		ExpectedOutput bool
+	}{
+		{
+			Name:           "ConnectionRefused",
+			Input:          &url.Error{Op: "Post", URL: "http://localhost", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}},
+			ExpectedOutput: true,
+		},
+		{
+			Name:           "UnexpectedEOF",
+			Input:          &url.Error{Op: "Post", URL: "http://localhost", Err: io.ErrUnexpectedEOF},
+			ExpectedOutput: true,
+		},
+		{
+			Name:           "Canceled",
+			Input:          &url.Error{Op: "Post", URL: "http://localhost", Err: context.Canceled},
+			ExpectedOutput: false,
+		},
+		{
+			Name:           "UnsupportedProtocol",
+			Input:          &url.Error{Op: "Post", URL: "foo://localhost", Err: errors.New("unsupported protocol scheme")},
+			ExpectedOutput: false,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			if e, a := test.ExpectedOutput, retryableError(test.Input); e != a {
+				t.Errorf("expected output to be %t, got %t", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
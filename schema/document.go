@@ -0,0 +1,448 @@
+package schema
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// QueryDocument is an executable GraphQL document, made of operations and the named fragments
+// they spread, e.g. the contents of a .graphql file of a client.
+type QueryDocument struct {
+	Operations []*OperationDefinition
+	Fragments  []*FragmentDefinition
+}
+
+// Fragment returns the fragment of the document with the given name, or nil if there is none.
+func (d *QueryDocument) Fragment(name string) *FragmentDefinition {
+	for _, f := range d.Fragments {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// OperationDefinition is an operation of an executable document.
+type OperationDefinition struct {
+	// Operation is the type of the operation, i.e. "query", "mutation", or "subscription".
+	Operation string
+
+	// Name is the name of the operation, it's empty for anonymous operations.
+	Name string
+
+	Variables    []*VariableDefinition
+	Directives   []*AppliedDirective
+	SelectionSet []*Selection
+}
+
+// VariableDefinition is a variable declared by an operation.
+type VariableDefinition struct {
+	Name string
+	Type *TypeRef
+
+	// DefaultValue is the default value of the variable, or nil if it has none.
+	DefaultValue *Value
+}
+
+// FragmentDefinition is a named fragment of an executable document.
+type FragmentDefinition struct {
+	Name          string
+	TypeCondition string
+	Directives    []*AppliedDirective
+	SelectionSet  []*Selection
+}
+
+// SelectionKind is the kind of a selection.
+type SelectionKind int
+
+// Kinds of selections.
+const (
+	// SelectionField is the selection of a field, e.g. user(id: $id) { name }.
+	SelectionField SelectionKind = iota
+
+	// SelectionInlineFragment is an inline fragment, e.g. ... on User { name }.
+	SelectionInlineFragment
+
+	// SelectionFragmentSpread is the spread of a named fragment, e.g. ...UserFields.
+	SelectionFragmentSpread
+)
+
+// Selection is a field, inline fragment, or spread of a named fragment selected on an object.
+type Selection struct {
+	Kind SelectionKind
+
+	// Alias, Name, and Arguments are those of fields. Name is the name of the fragment for
+	// fragment spreads.
+	Alias     string
+	Name      string
+	Arguments []*Argument
+
+	// TypeCondition is the type condition of inline fragments, it may be empty.
+	TypeCondition string
+
+	Directives   []*AppliedDirective
+	SelectionSet []*Selection
+}
+
+// ResponseKey returns the key of the data of a field in the response, i.e. its alias if it has
+// one and its name otherwise.
+func (s *Selection) ResponseKey() string {
+	if s.Alias != "" {
+		return s.Alias
+	}
+	return s.Name
+}
+
+// AppliedDirective is a directive applied to a selection or definition, e.g. @include(if: $x).
+type AppliedDirective struct {
+	Name      string
+	Arguments []*Argument
+}
+
+// Argument returns the value of the argument of the directive with the given name, or nil if it
+// wasn't passed.
+func (d *AppliedDirective) Argument(name string) *Value {
+	for _, arg := range d.Arguments {
+		if arg.Name == name {
+			return arg.Value
+		}
+	}
+	return nil
+}
+
+// Argument is an argument passed to a field or directive, or a field of an object value.
+type Argument struct {
+	Name  string
+	Value *Value
+}
+
+// ValueKind is the kind of a value.
+type ValueKind int
+
+// Kinds of values.
+const (
+	ValueVariable ValueKind = iota
+	ValueInt
+	ValueFloat
+	ValueString
+	ValueBoolean
+	ValueNull
+	ValueEnum
+	ValueList
+	ValueObject
+)
+
+// Value is a value passed as an argument, e.g. $id, 10, or {active: true}.
+type Value struct {
+	Kind ValueKind
+
+	// Raw is the name of variables, the unescaped contents of strings, and the literal text of
+	// any other scalar value.
+	Raw string
+
+	// List holds onto the items of list values.
+	List []*Value
+
+	// Fields holds onto the fields of object values.
+	Fields []*Argument
+}
+
+// String returns the value written in the GraphQL syntax, e.g. {names: ["a", "b"]}.
+func (v *Value) String() string {
+	switch v.Kind {
+	case ValueVariable:
+		return "$" + v.Raw
+	case ValueString:
+		return quote(v.Raw)
+	case ValueList:
+		items := make([]string, 0, len(v.List))
+		for _, item := range v.List {
+			items = append(items, item.String())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case ValueObject:
+		fields := make([]string, 0, len(v.Fields))
+		for _, field := range v.Fields {
+			fields = append(fields, field.Name+": "+field.Value.String())
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	default:
+		return v.Raw
+	}
+}
+
+// LoadQuery reads an executable document from r, see ParseQuery.
+func LoadQuery(r io.Reader) (*QueryDocument, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseQuery(string(b))
+}
+
+// ParseQuery parses an executable document, made of operations and named fragments, e.g.:
+//
+//	query GetUser($id: ID!) {
+//	  user(id: $id) {
+//	    ...UserFields
+//	  }
+//	}
+//
+//	fragment UserFields on User {
+//	  id
+//	  name
+//	}
+//
+// The document is only checked to be syntactically valid, it's not validated against any schema.
+// The shorthand form of queries, a selection set on its own, is parsed as an anonymous query.
+func ParseQuery(src string) (*QueryDocument, error) { //nolint:funlen
+	p := parser{lexer: newLexer(src)}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	var d QueryDocument
+	for p.tok.kind != tokenEOF {
+		if p.peek("{") {
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+
+			d.Operations = append(d.Operations, &OperationDefinition{Operation: "query", SelectionSet: selections})
+			continue
+		}
+
+		if p.tok.kind != tokenName {
+			return nil, p.errorf("expected definition, got %s", p.tok)
+		}
+
+		switch keyword := p.tok.value; keyword {
+		case "query", "mutation", "subscription":
+			op, err := p.operationDefinition()
+			if err != nil {
+				return nil, err
+			}
+			d.Operations = append(d.Operations, op)
+		case "fragment":
+			f, err := p.fragmentDefinition()
+			if err != nil {
+				return nil, err
+			}
+
+			if d.Fragment(f.Name) != nil {
+				return nil, fmt.Errorf("fragment %s is defined more than once", f.Name)
+			}
+			d.Fragments = append(d.Fragments, f)
+		default:
+			return nil, p.errorf("unexpected %s", p.tok)
+		}
+	}
+
+	return &d, nil
+}
+
+// operationDefinition consumes an operation definition, starting at its type.
+func (p *parser) operationDefinition() (*OperationDefinition, error) {
+	op := OperationDefinition{Operation: p.tok.value}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind == tokenName {
+		op.Name = p.tok.value
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if op.Variables, err = p.variableDefinitions(); err != nil {
+		return nil, err
+	}
+
+	if op.Directives, err = p.directives(false); err != nil {
+		return nil, err
+	}
+
+	if op.SelectionSet, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+
+	return &op, nil
+}
+
+// variableDefinitions consumes the variables declared by an operation, if there are any.
+func (p *parser) variableDefinitions() ([]*VariableDefinition, error) {
+	if ok, err := p.skip("("); err != nil || !ok {
+		return nil, err
+	}
+
+	var variables []*VariableDefinition
+	for !p.peek(")") {
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		v := VariableDefinition{Name: name}
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		if v.Type, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+
+		if ok, err := p.skip("="); err != nil {
+			return nil, err
+		} else if ok {
+			if v.DefaultValue, err = p.value(true); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err := p.directives(true); err != nil {
+			return nil, err
+		}
+
+		variables = append(variables, &v)
+	}
+
+	return variables, p.next()
+}
+
+// fragmentDefinition consumes the definition of a named fragment, starting at the fragment
+// keyword.
+func (p *parser) fragmentDefinition() (*FragmentDefinition, error) {
+	if err := p.expectKeyword("fragment"); err != nil {
+		return nil, err
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "on" {
+		return nil, p.errorf("fragment cannot be named \"on\"")
+	}
+
+	f := FragmentDefinition{Name: name}
+
+	if err := p.expectKeyword("on"); err != nil {
+		return nil, err
+	}
+
+	if f.TypeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+
+	if f.Directives, err = p.directives(false); err != nil {
+		return nil, err
+	}
+
+	if f.SelectionSet, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// selectionSet consumes a selection set enclosed by braces.
+func (p *parser) selectionSet() ([]*Selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var selections []*Selection
+	for !p.peek("}") {
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+
+	if len(selections) == 0 {
+		return nil, p.errorf("expected selection, got %s", p.tok)
+	}
+
+	return selections, p.next()
+}
+
+// selection consumes a field, inline fragment, or fragment spread.
+func (p *parser) selection() (*Selection, error) { //nolint:gocyclo
+	var s Selection
+	var err error
+
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		switch {
+		case p.peekKeyword("on"):
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+
+			s.Kind = SelectionInlineFragment
+			if s.TypeCondition, err = p.name(); err != nil {
+				return nil, err
+			}
+		case p.tok.kind == tokenName:
+			s.Kind = SelectionFragmentSpread
+			if s.Name, err = p.name(); err != nil {
+				return nil, err
+			}
+		default:
+			s.Kind = SelectionInlineFragment
+		}
+
+		if s.Directives, err = p.directives(false); err != nil {
+			return nil, err
+		}
+
+		if s.Kind == SelectionInlineFragment {
+			if s.SelectionSet, err = p.selectionSet(); err != nil {
+				return nil, err
+			}
+		}
+
+		return &s, nil
+	}
+
+	if s.Name, err = p.name(); err != nil {
+		return nil, err
+	}
+
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		s.Alias = s.Name
+		if s.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.Arguments, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+
+	if s.Directives, err = p.directives(false); err != nil {
+		return nil, err
+	}
+
+	if p.peek("{") {
+		if s.SelectionSet, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &s, nil
+}
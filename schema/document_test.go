@@ -0,0 +1,180 @@
+package schema
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+
+	d, err := ParseQuery(`
+query GetUser($id: ID!, $first: Int = 10) @cached {
+  user(id: $id) {
+    ...UserFields
+    friends: users(first: $first, filter: {names: ["a", "b"]}) @include(if: true) {
+      ... on User { id }
+      ... @skip(if: false) { name }
+    }
+  }
+}
+
+fragment UserFields on User {
+  id
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := &Selection{Kind: SelectionField, Name: "id"}
+	expected := &QueryDocument{
+		Operations: []*OperationDefinition{{
+			Operation: "query",
+			Name:      "GetUser",
+			Variables: []*VariableDefinition{
+				{Name: "id", Type: NonNullOf(Named("ID"))},
+				{Name: "first", Type: Named("Int"), DefaultValue: &Value{Kind: ValueInt, Raw: "10"}},
+			},
+			Directives: []*AppliedDirective{{Name: "cached"}},
+			SelectionSet: []*Selection{{
+				Kind:      SelectionField,
+				Name:      "user",
+				Arguments: []*Argument{{Name: "id", Value: &Value{Kind: ValueVariable, Raw: "id"}}},
+				SelectionSet: []*Selection{
+					{Kind: SelectionFragmentSpread, Name: "UserFields"},
+					{
+						Kind:  SelectionField,
+						Alias: "friends",
+						Name:  "users",
+						Arguments: []*Argument{
+							{Name: "first", Value: &Value{Kind: ValueVariable, Raw: "first"}},
+							{Name: "filter", Value: &Value{Kind: ValueObject, Fields: []*Argument{{
+								Name: "names",
+								Value: &Value{Kind: ValueList, List: []*Value{
+									{Kind: ValueString, Raw: "a"},
+									{Kind: ValueString, Raw: "b"},
+								}},
+							}}}},
+						},
+						Directives: []*AppliedDirective{{
+							Name:      "include",
+							Arguments: []*Argument{{Name: "if", Value: &Value{Kind: ValueBoolean, Raw: "true"}}},
+						}},
+						SelectionSet: []*Selection{
+							{Kind: SelectionInlineFragment, TypeCondition: "User", SelectionSet: []*Selection{id}},
+							{
+								Kind: SelectionInlineFragment,
+								Directives: []*AppliedDirective{{
+									Name:      "skip",
+									Arguments: []*Argument{{Name: "if", Value: &Value{Kind: ValueBoolean, Raw: "false"}}},
+								}},
+								SelectionSet: []*Selection{{Kind: SelectionField, Name: "name"}},
+							},
+						},
+					},
+				},
+			}},
+		}},
+		Fragments: []*FragmentDefinition{{Name: "UserFields", TypeCondition: "User", SelectionSet: []*Selection{id}}},
+	}
+
+	if diff := cmp.Diff(expected, d); diff != "" {
+		t.Errorf("unexpected document (-want +got):\n%s", diff)
+	}
+
+	if got := d.Operations[0].SelectionSet[0].SelectionSet[1].Arguments[1].Value.String(); got != `{names: ["a", "b"]}` {
+		t.Errorf("unexpected value %s", got)
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		Name  string
+		Input string
+	}{
+		{Name: "EmptySelectionSet", Input: `query { }`},
+		{Name: "DuplicateFragment", Input: `fragment A on User { id } fragment A on User { id }`},
+		{Name: "FragmentNamedOn", Input: `fragment on on User { id }`},
+		{Name: "TypeDefinition", Input: `type User { id: ID }`},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := ParseQuery(test.Input); err == nil {
+				t.Error("expected error")
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestWriteGraphQL(t *testing.T) {
+	t.Parallel()
+
+	input := `query GetUser($id: ID!, $first: Int = 10) @cached { user(id: $id) { ...UserFields,
+friends: users(first: $first, filter: {names: ["a", "b"]}) @include(if: true) { ... on User { id } ... @skip(if: false) { name } } } }
+{ viewer { id } }
+mutation ($name: String) { updateViewer(name: $name) { id } }
+fragment UserFields on User @cached { id }`
+
+	expected := `query GetUser($id: ID!, $first: Int = 10) @cached {
+  user(id: $id) {
+    ...UserFields
+    friends: users(first: $first, filter: {names: ["a", "b"]}) @include(if: true) {
+      ... on User {
+        id
+      }
+      ... @skip(if: false) {
+        name
+      }
+    }
+  }
+}
+
+query {
+  viewer {
+    id
+  }
+}
+
+mutation ($name: String) {
+  updateViewer(name: $name) {
+    id
+  }
+}
+
+fragment UserFields on User @cached {
+  id
+}
+`
+
+	d, err := ParseQuery(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := d.WriteGraphQL(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Errorf("unexpected document (-want +got):\n%s", diff)
+	}
+
+	written, err := ParseQuery(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(d, written); diff != "" {
+		t.Errorf("document changed when written and parsed again (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,64 @@
+package schema
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Load reads a schema from the file at path. Files with the .json extension are read as the
+// result of the introspection query, see LoadJSON, and files with the .graphql, .graphqls, .gql,
+// or .sdl extensions as SDL documents, see LoadSDL.
+func Load(path string) (*Schema, error) {
+	load, _, err := format(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s, err := load(f)
+	if err != nil {
+		return nil, fmt.Errorf("load schema from %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the schema to the file at path, in the format implied by its extension, see
+// Load.
+func (s *Schema) Save(path string) error {
+	_, write, err := format(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(s, f); err != nil {
+		f.Close() //nolint:errcheck // Why: the write error is the one worth returning.
+		return fmt.Errorf("save schema to %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// format returns the functions that read and write schemas in the format implied by the
+// extension of path.
+func format(path string) (func(io.Reader) (*Schema, error), func(*Schema, io.Writer) error, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return LoadJSON, (*Schema).WriteJSON, nil
+	case ".graphql", ".graphqls", ".gql", ".sdl":
+		return LoadSDL, (*Schema).WriteSDL, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown schema format of %s, expected a .json, .graphql, .graphqls, .gql, or .sdl file", path)
+	}
+}
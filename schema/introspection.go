@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// IntrospectionQuery is the query that introspects the schema of a GraphQL server. It doesn't ask
+// for the description of the schema, the specifiedByURL of scalars, or whether or not directives
+// are repeatable, which aren't supported by servers implementing older versions of the GraphQL
+// specification.
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      ...FullType
+    }
+    directives {
+      name
+      description
+      locations
+      args {
+        ...InputValue
+      }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args {
+      ...InputValue
+    }
+    type {
+      ...TypeRef
+    }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields {
+    ...InputValue
+  }
+  interfaces {
+    ...TypeRef
+  }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes {
+    ...TypeRef
+  }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type {
+    ...TypeRef
+  }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType {
+          kind
+          name
+          ofType {
+            kind
+            name
+            ofType {
+              kind
+              name
+              ofType {
+                kind
+                name
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// FromIntrospection converts the data of the response to IntrospectionQuery into a schema.
+func FromIntrospection(data json.RawMessage) (*Schema, error) {
+	var result introspection
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Schema == nil {
+		return nil, errors.New("missing __schema in introspection result")
+	}
+	return fromIntrospection(result.Schema)
+}
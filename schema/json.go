@@ -0,0 +1,350 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// introspection is the result of the introspection query, see IntrospectionQuery.
+type introspection struct {
+	Schema *introspectionSchema `json:"__schema"`
+}
+
+// introspectionSchema mirrors the __Schema type of the introspection system.
+type introspectionSchema struct {
+	Description      *string                  `json:"description,omitempty"`
+	QueryType        *introspectionName       `json:"queryType"`
+	MutationType     *introspectionName       `json:"mutationType"`
+	SubscriptionType *introspectionName       `json:"subscriptionType"`
+	Types            []introspectionType      `json:"types"`
+	Directives       []introspectionDirective `json:"directives"`
+}
+
+// introspectionName is a reference to a named type, e.g. the root operation types.
+type introspectionName struct {
+	Name string `json:"name"`
+}
+
+// introspectionType mirrors the __Type type of the introspection system.
+type introspectionType struct {
+	Kind           Kind                      `json:"kind"`
+	Name           *string                   `json:"name"`
+	Description    *string                   `json:"description"`
+	SpecifiedByURL *string                   `json:"specifiedByURL,omitempty"`
+	Fields         []introspectionField      `json:"fields"`
+	InputFields    []introspectionInputValue `json:"inputFields"`
+	Interfaces     []*TypeRef                `json:"interfaces"`
+	EnumValues     []introspectionEnumValue  `json:"enumValues"`
+	PossibleTypes  []*TypeRef                `json:"possibleTypes"`
+}
+
+// introspectionField mirrors the __Field type of the introspection system.
+type introspectionField struct {
+	Name              string                    `json:"name"`
+	Description       *string                   `json:"description"`
+	Args              []introspectionInputValue `json:"args"`
+	Type              *TypeRef                  `json:"type"`
+	IsDeprecated      bool                      `json:"isDeprecated"`
+	DeprecationReason *string                   `json:"deprecationReason"`
+}
+
+// introspectionInputValue mirrors the __InputValue type of the introspection system.
+type introspectionInputValue struct {
+	Name         string   `json:"name"`
+	Description  *string  `json:"description"`
+	Type         *TypeRef `json:"type"`
+	DefaultValue *string  `json:"defaultValue"`
+}
+
+// introspectionEnumValue mirrors the __EnumValue type of the introspection system.
+type introspectionEnumValue struct {
+	Name              string  `json:"name"`
+	Description       *string `json:"description"`
+	IsDeprecated      bool    `json:"isDeprecated"`
+	DeprecationReason *string `json:"deprecationReason"`
+}
+
+// introspectionDirective mirrors the __Directive type of the introspection system.
+type introspectionDirective struct {
+	Name         string                    `json:"name"`
+	Description  *string                   `json:"description"`
+	IsRepeatable bool                      `json:"isRepeatable,omitempty"`
+	Locations    []string                  `json:"locations"`
+	Args         []introspectionInputValue `json:"args"`
+}
+
+// typeRefJSON is the JSON representation of TypeRef, which follows the introspection system.
+type typeRefJSON struct {
+	Kind   Kind     `json:"kind"`
+	Name   *string  `json:"name"`
+	OfType *TypeRef `json:"ofType"`
+}
+
+// MarshalJSON implements json.Marshaler, writing the reference the way the introspection system
+// does, e.g. {"kind":"NON_NULL","name":null,"ofType":{"kind":"SCALAR","name":"ID","ofType":null}}.
+func (t *TypeRef) MarshalJSON() ([]byte, error) {
+	return json.Marshal(typeRefJSON{
+		Kind:   t.Kind,
+		Name:   optional(t.Name),
+		OfType: t.OfType,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading the reference the way the introspection
+// system writes it.
+func (t *TypeRef) UnmarshalJSON(b []byte) error {
+	var ref typeRefJSON
+	if err := json.Unmarshal(b, &ref); err != nil {
+		return err
+	}
+
+	*t = TypeRef{Kind: ref.Kind, Name: deref(ref.Name), OfType: ref.OfType}
+	return nil
+}
+
+// optional returns s as a pointer, or nil if s is empty.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// deref returns the value s points to, or an empty string if s is nil.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// LoadJSON reads a schema from r in the JSON format of the result of the introspection query,
+// see IntrospectionQuery. The result may be wrapped in the "data" key of a GraphQL response,
+// which is how it's commonly saved to files by other tools.
+func LoadJSON(r io.Reader) (*Schema, error) {
+	var result struct {
+		introspection
+		Data *introspection `json:"data"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	raw := result.Schema
+	if result.Data != nil {
+		raw = result.Data.Schema
+	}
+
+	if raw == nil {
+		return nil, errors.New("missing __schema in introspection result")
+	}
+
+	return fromIntrospection(raw)
+}
+
+// WriteJSON writes the schema to w in the JSON format of the result of the introspection query,
+// wrapped in the "data" key of a GraphQL response.
+func (s *Schema) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(struct {
+		Data introspection `json:"data"`
+	}{
+		Data: introspection{Schema: s.introspection()},
+	})
+}
+
+// fromIntrospection converts the result of the introspection query into a schema.
+func fromIntrospection(raw *introspectionSchema) (*Schema, error) {
+	s := Schema{
+		Description: deref(raw.Description),
+		Types:       make([]*Type, 0, len(raw.Types)),
+		Directives:  make([]*Directive, 0, len(raw.Directives)),
+	}
+
+	if raw.QueryType != nil {
+		s.QueryType = raw.QueryType.Name
+	}
+	if raw.MutationType != nil {
+		s.MutationType = raw.MutationType.Name
+	}
+	if raw.SubscriptionType != nil {
+		s.SubscriptionType = raw.SubscriptionType.Name
+	}
+
+	for i := range raw.Types {
+		rt := &raw.Types[i]
+
+		t := Type{
+			Kind:           rt.Kind,
+			Name:           deref(rt.Name),
+			Description:    deref(rt.Description),
+			SpecifiedByURL: deref(rt.SpecifiedByURL),
+			InputFields:    fromIntrospectionInputValues(rt.InputFields),
+		}
+
+		for j := range rt.Fields {
+			rf := &rt.Fields[j]
+			t.Fields = append(t.Fields, &Field{
+				Name:              rf.Name,
+				Description:       deref(rf.Description),
+				Args:              fromIntrospectionInputValues(rf.Args),
+				Type:              rf.Type,
+				IsDeprecated:      rf.IsDeprecated,
+				DeprecationReason: deref(rf.DeprecationReason),
+			})
+		}
+
+		for _, iface := range rt.Interfaces {
+			t.Interfaces = append(t.Interfaces, iface.Name)
+		}
+
+		for _, possible := range rt.PossibleTypes {
+			t.PossibleTypes = append(t.PossibleTypes, possible.Name)
+		}
+
+		for j := range rt.EnumValues {
+			rv := &rt.EnumValues[j]
+			t.EnumValues = append(t.EnumValues, &EnumValue{
+				Name:              rv.Name,
+				Description:       deref(rv.Description),
+				IsDeprecated:      rv.IsDeprecated,
+				DeprecationReason: deref(rv.DeprecationReason),
+			})
+		}
+
+		s.Types = append(s.Types, &t)
+	}
+
+	for i := range raw.Directives {
+		rd := &raw.Directives[i]
+		s.Directives = append(s.Directives, &Directive{
+			Name:         rd.Name,
+			Description:  deref(rd.Description),
+			Locations:    rd.Locations,
+			Args:         fromIntrospectionInputValues(rd.Args),
+			IsRepeatable: rd.IsRepeatable,
+		})
+	}
+
+	if err := s.Resolve(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// fromIntrospectionInputValues converts arguments or input fields from the result of the
+// introspection query.
+func fromIntrospectionInputValues(raw []introspectionInputValue) []*InputValue {
+	var values []*InputValue
+	for i := range raw {
+		values = append(values, &InputValue{
+			Name:         raw[i].Name,
+			Description:  deref(raw[i].Description),
+			Type:         raw[i].Type,
+			DefaultValue: raw[i].DefaultValue,
+		})
+	}
+	return values
+}
+
+// introspection converts the schema into the result of the introspection query.
+func (s *Schema) introspection() *introspectionSchema {
+	raw := introspectionSchema{
+		Description: optional(s.Description),
+		Types:       make([]introspectionType, 0, len(s.Types)),
+		Directives:  make([]introspectionDirective, 0, len(s.Directives)),
+	}
+
+	if s.QueryType != "" {
+		raw.QueryType = &introspectionName{Name: s.QueryType}
+	}
+	if s.MutationType != "" {
+		raw.MutationType = &introspectionName{Name: s.MutationType}
+	}
+	if s.SubscriptionType != "" {
+		raw.SubscriptionType = &introspectionName{Name: s.SubscriptionType}
+	}
+
+	for _, t := range s.Types {
+		rt := introspectionType{
+			Kind:           t.Kind,
+			Name:           optional(t.Name),
+			Description:    optional(t.Description),
+			SpecifiedByURL: optional(t.SpecifiedByURL),
+		}
+
+		// The introspection system reports null rather than empty lists for the fields that
+		// don't apply to the kind of the type.
+		switch t.Kind { //nolint:exhaustive // Why: the other kinds have none of these.
+		case KindObject, KindInterface:
+			rt.Fields = make([]introspectionField, 0, len(t.Fields))
+			for _, f := range t.Fields {
+				rt.Fields = append(rt.Fields, introspectionField{
+					Name:              f.Name,
+					Description:       optional(f.Description),
+					Args:              introspectionInputValues(f.Args),
+					Type:              f.Type,
+					IsDeprecated:      f.IsDeprecated,
+					DeprecationReason: optional(f.DeprecationReason),
+				})
+			}
+
+			rt.Interfaces = make([]*TypeRef, 0, len(t.Interfaces))
+			for _, name := range t.Interfaces {
+				rt.Interfaces = append(rt.Interfaces, &TypeRef{Kind: KindInterface, Name: name})
+			}
+		case KindEnum:
+			rt.EnumValues = make([]introspectionEnumValue, 0, len(t.EnumValues))
+			for _, v := range t.EnumValues {
+				rt.EnumValues = append(rt.EnumValues, introspectionEnumValue{
+					Name:              v.Name,
+					Description:       optional(v.Description),
+					IsDeprecated:      v.IsDeprecated,
+					DeprecationReason: optional(v.DeprecationReason),
+				})
+			}
+		case KindInputObject:
+			rt.InputFields = introspectionInputValues(t.InputFields)
+		}
+
+		if t.Kind == KindInterface || t.Kind == KindUnion {
+			rt.PossibleTypes = make([]*TypeRef, 0, len(t.PossibleTypes))
+			for _, name := range t.PossibleTypes {
+				rt.PossibleTypes = append(rt.PossibleTypes, &TypeRef{Kind: KindObject, Name: name})
+			}
+		}
+
+		raw.Types = append(raw.Types, rt)
+	}
+
+	for _, d := range s.Directives {
+		raw.Directives = append(raw.Directives, introspectionDirective{
+			Name:         d.Name,
+			Description:  optional(d.Description),
+			IsRepeatable: d.IsRepeatable,
+			Locations:    d.Locations,
+			Args:         introspectionInputValues(d.Args),
+		})
+	}
+
+	return &raw
+}
+
+// introspectionInputValues converts arguments or input fields into the result of the
+// introspection query.
+func introspectionInputValues(values []*InputValue) []introspectionInputValue {
+	raw := make([]introspectionInputValue, 0, len(values))
+	for _, v := range values {
+		raw = append(raw, introspectionInputValue{
+			Name:         v.Name,
+			Description:  optional(v.Description),
+			Type:         v.Type,
+			DefaultValue: v.DefaultValue,
+		})
+	}
+	return raw
+}
@@ -0,0 +1,111 @@
+package schema
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	s, err := ParseSDL(testSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := s.WriteJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadJSON(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(s, loaded); diff != "" {
+		t.Errorf("expected schema to be the same after a JSON round trip (-want +got):\n%s", diff)
+	}
+}
+
+// testIntrospection is the result of the introspection query for a schema with a single field.
+const testIntrospection = `{
+  "__schema": {
+    "queryType": {"name": "Query"},
+    "mutationType": null,
+    "subscriptionType": null,
+    "types": [
+      {
+        "kind": "OBJECT",
+        "name": "Query",
+        "fields": [
+          {
+            "name": "id",
+            "args": [],
+            "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID", "ofType": null}},
+            "isDeprecated": false,
+            "deprecationReason": null
+          }
+        ],
+        "interfaces": []
+      },
+      {"kind": "SCALAR", "name": "ID"}
+    ],
+    "directives": []
+  }
+}`
+
+func TestLoadJSON(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		Name          string
+		Input         string
+		ExpectedError bool
+	}{
+		{
+			Name:  "Unwrapped",
+			Input: testIntrospection,
+		},
+		{
+			Name:  "Wrapped",
+			Input: `{"data": ` + testIntrospection + `}`,
+		},
+		{
+			Name:          "Missing",
+			Input:         `{"data": {}}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Undefined",
+			Input:         strings.Replace(testIntrospection, `{"kind": "SCALAR", "name": "ID"}`, `{"kind": "SCALAR", "name": "String"}`, 1),
+			ExpectedError: true,
+		},
+	}
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			s, err := LoadJSON(strings.NewReader(test.Input))
+			if test.ExpectedError {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s.RootType("query").Field("id").Type.String() != "ID!" {
+				t.Errorf("unexpected type of id %s", s.RootType("query").Field("id").Type)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
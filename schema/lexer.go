@@ -0,0 +1,303 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind is the kind of a lexical token of a GraphQL document.
+type tokenKind int
+
+// Kinds of lexical tokens.
+const (
+	// tokenEOF is the end of the document.
+	tokenEOF tokenKind = iota
+
+	// tokenPunctuator is one of ! $ & ( ) ... : = @ [ ] { | }.
+	tokenPunctuator
+
+	// tokenName is a name, e.g. of a type, field, or keyword.
+	tokenName
+
+	// tokenInt is an int literal.
+	tokenInt
+
+	// tokenFloat is a float literal.
+	tokenFloat
+
+	// tokenString is a string literal, either quoted or a block string.
+	tokenString
+)
+
+// token is a lexical token of a GraphQL document.
+type token struct {
+	kind tokenKind
+
+	// value is the text of the token, strings are unescaped.
+	value string
+
+	// block denotes whether or not a string token is a block string.
+	block bool
+
+	// line is the line of the document that the token starts on.
+	line int
+}
+
+// String returns a description of the token for error messages.
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of document"
+	case tokenString:
+		return strconv.Quote(t.value)
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+// lexer splits a GraphQL document into lexical tokens.
+type lexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+// newLexer returns a lexer that reads through src.
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1}
+}
+
+// errorf returns an error that points at the current line of the lexer.
+func (l *lexer) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", l.line, fmt.Sprintf(format, args...))
+}
+
+// skipIgnored consumes whitespace, commas, and comments, which are insignificant in GraphQL.
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case '\n':
+			l.line++
+			l.pos++
+		case ' ', '\t', '\r', ',':
+			l.pos++
+		case '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			// The byte order mark is ignored as well.
+			if strings.HasPrefix(l.src[l.pos:], "\ufeff") {
+				l.pos += len("\ufeff")
+				continue
+			}
+			return
+		}
+	}
+}
+
+// next consumes and returns the next token.
+func (l *lexer) next() (token, error) { //nolint:gocyclo
+	l.skipIgnored()
+
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, line: l.line}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokenPunctuator, value: "...", line: l.line}, nil
+	case strings.ContainsRune("!$&():=@[]{|}", rune(c)):
+		l.pos++
+		return token{kind: tokenPunctuator, value: string(c), line: l.line}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.src[start:l.pos], line: l.line}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case strings.HasPrefix(l.src[l.pos:], `"""`):
+		return l.blockString()
+	case c == '"':
+		return l.string()
+	}
+
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, l.errorf("unexpected character %q", r)
+}
+
+// number consumes an int or float literal.
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	kind := tokenInt
+
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+
+	digits := func() error {
+		begin := l.pos
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+
+		if begin == l.pos {
+			return l.errorf("invalid number %q", l.src[start:l.pos])
+		}
+		return nil
+	}
+
+	if err := digits(); err != nil {
+		return token{}, err
+	}
+
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		if err := digits(); err != nil {
+			return token{}, err
+		}
+	}
+
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if err := digits(); err != nil {
+			return token{}, err
+		}
+	}
+
+	return token{kind: kind, value: l.src[start:l.pos], line: l.line}, nil
+}
+
+// string consumes a quoted string literal and unescapes it.
+func (l *lexer) string() (token, error) {
+	var sb strings.Builder
+
+	for l.pos++; l.pos < len(l.src); l.pos++ {
+		switch c := l.src[l.pos]; c {
+		case '"':
+			l.pos++
+			return token{kind: tokenString, value: sb.String(), line: l.line}, nil
+		case '\n':
+			return token{}, l.errorf("unterminated string")
+		case '\\':
+			l.pos++
+			if l.pos >= len(l.src) {
+				return token{}, l.errorf("unterminated string")
+			}
+
+			switch e := l.src[l.pos]; e {
+			case '"', '\\', '/':
+				sb.WriteByte(e)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if l.pos+4 >= len(l.src) {
+					return token{}, l.errorf("invalid unicode escape")
+				}
+
+				code, err := strconv.ParseUint(l.src[l.pos+1:l.pos+5], 16, 32)
+				if err != nil {
+					return token{}, l.errorf("invalid unicode escape %q", l.src[l.pos-1:l.pos+5])
+				}
+				sb.WriteRune(rune(code))
+				l.pos += 4
+			default:
+				return token{}, l.errorf("invalid escape sequence \\%c", e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return token{}, l.errorf("unterminated string")
+}
+
+// blockString consumes a block string literal and strips its common indentation.
+func (l *lexer) blockString() (token, error) {
+	line := l.line
+	l.pos += 3
+
+	var sb strings.Builder
+	for l.pos < len(l.src) {
+		switch {
+		case strings.HasPrefix(l.src[l.pos:], `\"""`):
+			sb.WriteString(`"""`)
+			l.pos += 4
+		case strings.HasPrefix(l.src[l.pos:], `"""`):
+			l.pos += 3
+			return token{kind: tokenString, value: blockStringValue(sb.String()), block: true, line: line}, nil
+		default:
+			if l.src[l.pos] == '\n' {
+				l.line++
+			}
+			sb.WriteByte(l.src[l.pos])
+			l.pos++
+		}
+	}
+
+	return token{}, l.errorf("unterminated block string")
+}
+
+// blockStringValue strips the common indentation and the leading and trailing blank lines of
+// the raw value of a block string, as per the GraphQL specification.
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+
+	common := -1
+	for _, line := range lines[1:] {
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < len(line) && (common == -1 || indent < common) {
+			common = indent
+		}
+	}
+
+	if common > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= common {
+				lines[i] = lines[i][common:]
+			} else {
+				lines[i] = ""
+			}
+		}
+	}
+
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// isLetter denotes whether or not c is an ASCII letter.
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isDigit denotes whether or not c is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
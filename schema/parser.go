@@ -0,0 +1,262 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// parser parses GraphQL documents from the tokens read by a lexer.
+type parser struct {
+	lexer *lexer
+	tok   token
+}
+
+// next advances the parser to the next token.
+func (p *parser) next() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+// errorf returns an error that points at the line of the current token.
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.tok.line, fmt.Sprintf(format, args...))
+}
+
+// peek denotes whether or not the current token is the given punctuator.
+func (p *parser) peek(punctuator string) bool {
+	return p.tok.kind == tokenPunctuator && p.tok.value == punctuator
+}
+
+// peekKeyword denotes whether or not the current token is the given keyword.
+func (p *parser) peekKeyword(keyword string) bool {
+	return p.tok.kind == tokenName && p.tok.value == keyword
+}
+
+// skip consumes the given punctuator if it is the current token, and denotes whether or not it
+// was.
+func (p *parser) skip(punctuator string) (bool, error) {
+	if !p.peek(punctuator) {
+		return false, nil
+	}
+	return true, p.next()
+}
+
+// expect consumes the given punctuator or returns an error if it is not the current token.
+func (p *parser) expect(punctuator string) error {
+	if !p.peek(punctuator) {
+		return p.errorf("expected %q, got %s", punctuator, p.tok)
+	}
+	return p.next()
+}
+
+// expectKeyword consumes the given keyword or returns an error if it is not the current token.
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.peekKeyword(keyword) {
+		return p.errorf("expected %q, got %s", keyword, p.tok)
+	}
+	return p.next()
+}
+
+// name consumes a name.
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.errorf("expected name, got %s", p.tok)
+	}
+
+	name := p.tok.value
+	return name, p.next()
+}
+
+// description consumes the description preceding a definition, if there is one.
+func (p *parser) description() (string, error) {
+	if p.tok.kind != tokenString {
+		return "", nil
+	}
+
+	description := p.tok.value
+	return description, p.next()
+}
+
+// typeRef consumes a type reference, e.g. [ID!]!.
+func (p *parser) typeRef() (*TypeRef, error) {
+	var t *TypeRef
+
+	if ok, err := p.skip("["); err != nil {
+		return nil, err
+	} else if ok {
+		ofType, err := p.typeRef()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		t = ListOf(ofType)
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		t = Named(name)
+	}
+
+	if ok, err := p.skip("!"); err != nil {
+		return nil, err
+	} else if ok {
+		t = NonNullOf(t)
+	}
+
+	return t, nil
+}
+
+// value consumes a value. Variables are only allowed if constant is false.
+func (p *parser) value(constant bool) (*Value, error) { //nolint:gocyclo
+	tok := p.tok
+
+	switch {
+	case tok.kind == tokenInt:
+		return &Value{Kind: ValueInt, Raw: tok.value}, p.next()
+	case tok.kind == tokenFloat:
+		return &Value{Kind: ValueFloat, Raw: tok.value}, p.next()
+	case tok.kind == tokenString:
+		return &Value{Kind: ValueString, Raw: tok.value}, p.next()
+	case tok.kind == tokenName:
+		kind := ValueEnum
+		switch tok.value {
+		case "true", "false":
+			kind = ValueBoolean
+		case "null":
+			kind = ValueNull
+		}
+		return &Value{Kind: kind, Raw: tok.value}, p.next()
+	case p.peek("$"):
+		if constant {
+			return nil, p.errorf("unexpected variable in constant value")
+		}
+
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		return &Value{Kind: ValueVariable, Raw: name}, nil
+	case p.peek("["):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		list := Value{Kind: ValueList}
+		for !p.peek("]") {
+			if p.tok.kind == tokenEOF {
+				return nil, p.errorf("expected \"]\", got %s", p.tok)
+			}
+
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list.List = append(list.List, item)
+		}
+		return &list, p.next()
+	case p.peek("{"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		object := Value{Kind: ValueObject}
+		for !p.peek("}") {
+			field, err := p.argument(constant)
+			if err != nil {
+				return nil, err
+			}
+			object.Fields = append(object.Fields, field)
+		}
+		return &object, p.next()
+	}
+
+	return nil, p.errorf("expected value, got %s", p.tok)
+}
+
+// quote returns s as a quoted GraphQL string literal.
+func quote(s string) string {
+	var b bytes.Buffer
+
+	// GraphQL strings are escaped the same way as JSON strings, other than HTML characters,
+	// which don't need to be escaped at all.
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s) //nolint:errcheck // Why: encoding a string can't fail.
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// argument consumes a name and a value separated by a colon, i.e. an argument or a field of an
+// object value.
+func (p *parser) argument(constant bool) (*Argument, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+
+	value, err := p.value(constant)
+	if err != nil {
+		return nil, err
+	}
+	return &Argument{Name: name, Value: value}, nil
+}
+
+// arguments consumes the arguments passed to a field or directive, if there are any.
+func (p *parser) arguments(constant bool) ([]*Argument, error) {
+	if ok, err := p.skip("("); err != nil || !ok {
+		return nil, err
+	}
+
+	var args []*Argument
+	for !p.peek(")") {
+		arg, err := p.argument(constant)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	return args, p.next()
+}
+
+// directives consumes the directives applied to a definition or selection, if there are any.
+func (p *parser) directives(constant bool) ([]*AppliedDirective, error) {
+	var directives []*AppliedDirective
+	for p.peek("@") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		args, err := p.arguments(constant)
+		if err != nil {
+			return nil, err
+		}
+
+		directives = append(directives, &AppliedDirective{Name: name, Arguments: args})
+	}
+
+	return directives, nil
+}
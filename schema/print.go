@@ -0,0 +1,372 @@
+package schema
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteSDL writes the schema to w in the GraphQL schema definition language. The builtin scalar
+// types and directives and the types of the introspection system are left out, and so is the
+// schema definition if the root operation types are named Query, Mutation, and Subscription.
+func (s *Schema) WriteSDL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	pw := printer{w: bw}
+
+	if s.Description != "" || !s.hasDefaultRootTypes() {
+		pw.separate()
+		pw.description("", s.Description)
+		io.WriteString(bw, "schema {\n") //nolint:errcheck
+		for _, root := range []struct{ operation, name string }{
+			{"query", s.QueryType},
+			{"mutation", s.MutationType},
+			{"subscription", s.SubscriptionType},
+		} {
+			if root.name != "" {
+				fmt.Fprintf(bw, "  %s: %s\n", root.operation, root.name) //nolint:errcheck
+			}
+		}
+		io.WriteString(bw, "}\n") //nolint:errcheck
+	}
+
+	for _, d := range s.Directives {
+		if isBuiltinDirective(d.Name) {
+			continue
+		}
+		pw.separate()
+		pw.directive(d)
+	}
+
+	for _, t := range s.Types {
+		if strings.HasPrefix(t.Name, "__") || (t.Kind == KindScalar && isBuiltinScalar(t.Name)) {
+			continue
+		}
+		pw.separate()
+		pw.typ(t)
+	}
+
+	return bw.Flush()
+}
+
+// hasDefaultRootTypes denotes whether or not the root operation types of the schema are the
+// ones assumed when a document has no schema definition.
+func (s *Schema) hasDefaultRootTypes() bool {
+	for _, root := range []struct{ name, dflt string }{
+		{s.QueryType, "Query"},
+		{s.MutationType, "Mutation"},
+		{s.SubscriptionType, "Subscription"},
+	} {
+		if root.name != "" && root.name != root.dflt {
+			return false
+		}
+
+		// A type with the default name that isn't the root operation type would become it
+		// when the document is parsed again.
+		if t := s.Type(root.dflt); root.name == "" && t != nil && t.Kind == KindObject {
+			return false
+		}
+	}
+	return true
+}
+
+// printer writes the definitions of a schema in the GraphQL schema definition language.
+type printer struct {
+	w *bufio.Writer
+
+	// started denotes whether or not a definition was written already, definitions are
+	// separated by blank lines.
+	started bool
+}
+
+// separate writes the blank line between two definitions, if one was written already.
+func (p *printer) separate() {
+	if p.started {
+		p.w.WriteByte('\n') //nolint:errcheck
+	}
+	p.started = true
+}
+
+// description writes the description of a definition as a block string, with the given
+// indentation.
+func (p *printer) description(indent, description string) {
+	if description == "" {
+		return
+	}
+
+	// Block strings that hold nothing but whitespace read back as empty, so such descriptions
+	// are written as regular strings instead.
+	if strings.TrimSpace(description) == "" {
+		fmt.Fprintf(p.w, "%s%s\n", indent, quote(description)) //nolint:errcheck
+		return
+	}
+
+	if !strings.Contains(description, "\n") && !strings.HasSuffix(description, `"`) {
+		fmt.Fprintf(p.w, "%s\"\"\"%s\"\"\"\n", indent, escapeBlockString(description)) //nolint:errcheck
+		return
+	}
+
+	fmt.Fprintf(p.w, "%s\"\"\"\n", indent) //nolint:errcheck
+	for _, line := range strings.Split(escapeBlockString(description), "\n") {
+		if line == "" {
+			p.w.WriteByte('\n') //nolint:errcheck
+			continue
+		}
+		fmt.Fprintf(p.w, "%s%s\n", indent, line) //nolint:errcheck
+	}
+	fmt.Fprintf(p.w, "%s\"\"\"\n", indent) //nolint:errcheck
+}
+
+// escapeBlockString escapes the triple quotes within s, which would end a block string.
+func escapeBlockString(s string) string {
+	return strings.ReplaceAll(s, `"""`, `\"""`)
+}
+
+// directive writes the definition of a directive.
+func (p *printer) directive(d *Directive) {
+	p.description("", d.Description)
+	fmt.Fprintf(p.w, "directive @%s", d.Name) //nolint:errcheck
+	p.arguments("", d.Args)
+
+	if d.IsRepeatable {
+		io.WriteString(p.w, " repeatable") //nolint:errcheck
+	}
+	fmt.Fprintf(p.w, " on %s\n", strings.Join(d.Locations, " | ")) //nolint:errcheck
+}
+
+// typ writes the definition of a named type.
+func (p *printer) typ(t *Type) { //nolint:gocyclo
+	p.description("", t.Description)
+
+	switch t.Kind { //nolint:exhaustive // Why: lists and non-null types aren't named types.
+	case KindScalar:
+		fmt.Fprintf(p.w, "scalar %s", t.Name) //nolint:errcheck
+		if t.SpecifiedByURL != "" {
+			fmt.Fprintf(p.w, " @specifiedBy(url: %s)", quote(t.SpecifiedByURL)) //nolint:errcheck
+		}
+		p.w.WriteByte('\n') //nolint:errcheck
+	case KindObject, KindInterface:
+		keyword := "type"
+		if t.Kind == KindInterface {
+			keyword = "interface"
+		}
+
+		fmt.Fprintf(p.w, "%s %s", keyword, t.Name) //nolint:errcheck
+		if len(t.Interfaces) > 0 {
+			fmt.Fprintf(p.w, " implements %s", strings.Join(t.Interfaces, " & ")) //nolint:errcheck
+		}
+
+		p.block(len(t.Fields), func(i int) {
+			f := t.Fields[i]
+			p.description("  ", f.Description)
+			fmt.Fprintf(p.w, "  %s", f.Name) //nolint:errcheck
+			p.arguments("  ", f.Args)
+			fmt.Fprintf(p.w, ": %s", f.Type) //nolint:errcheck
+			p.deprecated(f.IsDeprecated, f.DeprecationReason)
+		})
+	case KindUnion:
+		fmt.Fprintf(p.w, "union %s", t.Name) //nolint:errcheck
+		if len(t.PossibleTypes) > 0 {
+			fmt.Fprintf(p.w, " = %s", strings.Join(t.PossibleTypes, " | ")) //nolint:errcheck
+		}
+		p.w.WriteByte('\n') //nolint:errcheck
+	case KindEnum:
+		fmt.Fprintf(p.w, "enum %s", t.Name) //nolint:errcheck
+		p.block(len(t.EnumValues), func(i int) {
+			v := t.EnumValues[i]
+			p.description("  ", v.Description)
+			fmt.Fprintf(p.w, "  %s", v.Name) //nolint:errcheck
+			p.deprecated(v.IsDeprecated, v.DeprecationReason)
+		})
+	case KindInputObject:
+		fmt.Fprintf(p.w, "input %s", t.Name) //nolint:errcheck
+		p.block(len(t.InputFields), func(i int) {
+			p.description("  ", t.InputFields[i].Description)
+			io.WriteString(p.w, "  ") //nolint:errcheck
+			p.inputValue(t.InputFields[i])
+		})
+	}
+}
+
+// block writes the n members of a definition between braces, one per line, through member.
+func (p *printer) block(n int, member func(i int)) {
+	if n == 0 {
+		p.w.WriteByte('\n') //nolint:errcheck
+		return
+	}
+
+	io.WriteString(p.w, " {\n") //nolint:errcheck
+	for i := 0; i < n; i++ {
+		member(i)
+		p.w.WriteByte('\n') //nolint:errcheck
+	}
+	io.WriteString(p.w, "}\n") //nolint:errcheck
+}
+
+// arguments writes the argument definitions of a field or directive, if there are any. They're
+// written on a line each, with the given indentation, if any of them has a description.
+func (p *printer) arguments(indent string, args []*InputValue) {
+	if len(args) == 0 {
+		return
+	}
+
+	multiline := false
+	for _, arg := range args {
+		multiline = multiline || arg.Description != ""
+	}
+
+	if !multiline {
+		p.w.WriteByte('(') //nolint:errcheck
+		for i, arg := range args {
+			if i > 0 {
+				io.WriteString(p.w, ", ") //nolint:errcheck
+			}
+			p.inputValue(arg)
+		}
+		p.w.WriteByte(')') //nolint:errcheck
+		return
+	}
+
+	io.WriteString(p.w, "(\n") //nolint:errcheck
+	for _, arg := range args {
+		p.description(indent+"  ", arg.Description)
+		io.WriteString(p.w, indent+"  ") //nolint:errcheck
+		p.inputValue(arg)
+		p.w.WriteByte('\n') //nolint:errcheck
+	}
+	io.WriteString(p.w, indent+")") //nolint:errcheck
+}
+
+// inputValue writes the definition of an argument or input field, without its description.
+func (p *printer) inputValue(v *InputValue) {
+	fmt.Fprintf(p.w, "%s: %s", v.Name, v.Type) //nolint:errcheck
+	if v.DefaultValue != nil {
+		fmt.Fprintf(p.w, " = %s", *v.DefaultValue) //nolint:errcheck
+	}
+}
+
+// deprecated writes the @deprecated directive, if the definition it would be applied to is
+// deprecated.
+func (p *printer) deprecated(isDeprecated bool, reason string) {
+	if !isDeprecated {
+		return
+	}
+
+	if reason == "" || reason == defaultDeprecationReason {
+		io.WriteString(p.w, " @deprecated") //nolint:errcheck
+		return
+	}
+	fmt.Fprintf(p.w, " @deprecated(reason: %s)", quote(reason)) //nolint:errcheck
+}
+
+// WriteGraphQL writes the document to w in the GraphQL syntax, pretty-printed with a selection
+// per line, indented by two spaces per level, and with the operations and named fragments
+// separated by blank lines.
+func (d *QueryDocument) WriteGraphQL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	pw := printer{w: bw}
+
+	for _, op := range d.Operations {
+		pw.separate()
+		io.WriteString(bw, op.Operation) //nolint:errcheck
+		if op.Name != "" {
+			fmt.Fprintf(bw, " %s", op.Name) //nolint:errcheck
+		}
+		pw.variableDefinitions(op.Name == "", op.Variables)
+		pw.appliedDirectives(op.Directives)
+		pw.selectionSet("", op.SelectionSet)
+	}
+
+	for _, f := range d.Fragments {
+		pw.separate()
+		fmt.Fprintf(bw, "fragment %s on %s", f.Name, f.TypeCondition) //nolint:errcheck
+		pw.appliedDirectives(f.Directives)
+		pw.selectionSet("", f.SelectionSet)
+	}
+
+	return bw.Flush()
+}
+
+// variableDefinitions writes the variables declared by an operation, if there are any. They're
+// separated from the operation type by a space if the operation is anonymous, e.g.
+// query ($id: ID!) rather than query GetUser($id: ID!).
+func (p *printer) variableDefinitions(anonymous bool, variables []*VariableDefinition) {
+	if len(variables) == 0 {
+		return
+	}
+
+	if anonymous {
+		p.w.WriteByte(' ') //nolint:errcheck
+	}
+
+	p.w.WriteByte('(') //nolint:errcheck
+	for i, v := range variables {
+		if i > 0 {
+			io.WriteString(p.w, ", ") //nolint:errcheck
+		}
+
+		fmt.Fprintf(p.w, "$%s: %s", v.Name, v.Type) //nolint:errcheck
+		if v.DefaultValue != nil {
+			fmt.Fprintf(p.w, " = %s", v.DefaultValue) //nolint:errcheck
+		}
+	}
+	p.w.WriteByte(')') //nolint:errcheck
+}
+
+// selectionSet writes a selection set between braces, with a selection per line indented by two
+// spaces more than the given indentation, followed by a newline.
+func (p *printer) selectionSet(indent string, selections []*Selection) {
+	io.WriteString(p.w, " {\n") //nolint:errcheck
+	for _, s := range selections {
+		io.WriteString(p.w, indent+"  ") //nolint:errcheck
+
+		switch s.Kind {
+		case SelectionField:
+			if s.Alias != "" {
+				fmt.Fprintf(p.w, "%s: ", s.Alias) //nolint:errcheck
+			}
+			io.WriteString(p.w, s.Name) //nolint:errcheck
+			p.appliedArguments(s.Arguments)
+		case SelectionInlineFragment:
+			io.WriteString(p.w, "...") //nolint:errcheck
+			if s.TypeCondition != "" {
+				fmt.Fprintf(p.w, " on %s", s.TypeCondition) //nolint:errcheck
+			}
+		case SelectionFragmentSpread:
+			fmt.Fprintf(p.w, "...%s", s.Name) //nolint:errcheck
+		}
+
+		p.appliedDirectives(s.Directives)
+		if len(s.SelectionSet) > 0 {
+			p.selectionSet(indent+"  ", s.SelectionSet)
+			continue
+		}
+		p.w.WriteByte('\n') //nolint:errcheck
+	}
+	fmt.Fprintf(p.w, "%s}\n", indent) //nolint:errcheck
+}
+
+// appliedArguments writes the arguments passed to a field or directive, if there are any.
+func (p *printer) appliedArguments(args []*Argument) {
+	if len(args) == 0 {
+		return
+	}
+
+	p.w.WriteByte('(') //nolint:errcheck
+	for i, arg := range args {
+		if i > 0 {
+			io.WriteString(p.w, ", ") //nolint:errcheck
+		}
+		fmt.Fprintf(p.w, "%s: %s", arg.Name, arg.Value) //nolint:errcheck
+	}
+	p.w.WriteByte(')') //nolint:errcheck
+}
+
+// appliedDirectives writes the directives applied to a selection or definition, each preceded by
+// a space.
+func (p *printer) appliedDirectives(directives []*AppliedDirective) {
+	for _, d := range directives {
+		fmt.Fprintf(p.w, " @%s", d.Name) //nolint:errcheck
+		p.appliedArguments(d.Arguments)
+	}
+}
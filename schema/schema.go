@@ -0,0 +1,370 @@
+// Package schema models the schema of a GraphQL server, as returned by introspecting it or as
+// described by a document written in the GraphQL schema definition language (SDL). Schemas can be
+// loaded from and saved to both, which allows them to be checked into repositories and used
+// offline. The package also parses the executable documents, made of operations and fragments,
+// that are run against schemas.
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the kind of a GraphQL type, as reported by the __TypeKind enum of the introspection
+// system.
+type Kind string
+
+// Kinds of GraphQL types.
+const (
+	// KindScalar is the kind of scalar types, e.g. String or DateTime.
+	KindScalar Kind = "SCALAR"
+
+	// KindObject is the kind of object types.
+	KindObject Kind = "OBJECT"
+
+	// KindInterface is the kind of interface types.
+	KindInterface Kind = "INTERFACE"
+
+	// KindUnion is the kind of union types.
+	KindUnion Kind = "UNION"
+
+	// KindEnum is the kind of enum types.
+	KindEnum Kind = "ENUM"
+
+	// KindInputObject is the kind of input object types.
+	KindInputObject Kind = "INPUT_OBJECT"
+
+	// KindList is the kind of list type references.
+	KindList Kind = "LIST"
+
+	// KindNonNull is the kind of non-null type references.
+	KindNonNull Kind = "NON_NULL"
+)
+
+// builtinScalars are the scalar types that every GraphQL schema has, which aren't written
+// out in SDL documents.
+var builtinScalars = []string{"Int", "Float", "String", "Boolean", "ID"}
+
+// Schema is the schema of a GraphQL server.
+type Schema struct {
+	Description string
+
+	// QueryType, MutationType, and SubscriptionType are the names of the root operation types of
+	// the schema. The latter two are empty if the schema doesn't support mutations or
+	// subscriptions.
+	QueryType        string
+	MutationType     string
+	SubscriptionType string
+
+	// Types holds onto the named types of the schema, in the order they were defined in.
+	Types []*Type
+
+	// Directives holds onto the directives that the schema supports.
+	Directives []*Directive
+}
+
+// Type returns the named type of the schema with the given name, or nil if there is none.
+func (s *Schema) Type(name string) *Type {
+	for _, t := range s.Types {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Directive returns the directive of the schema with the given name, or nil if there is none.
+func (s *Schema) Directive(name string) *Directive {
+	for _, d := range s.Directives {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// RootType returns the root type of the given operation type ("query", "mutation", or
+// "subscription"), or nil if the schema doesn't support it.
+func (s *Schema) RootType(operation string) *Type {
+	var name string
+	switch operation {
+	case "query":
+		name = s.QueryType
+	case "mutation":
+		name = s.MutationType
+	case "subscription":
+		name = s.SubscriptionType
+	}
+
+	if name == "" {
+		return nil
+	}
+	return s.Type(name)
+}
+
+// Type is a named type of a schema.
+type Type struct {
+	Kind        Kind
+	Name        string
+	Description string
+
+	// SpecifiedByURL is the URL of the specification of a custom scalar type, if any.
+	SpecifiedByURL string
+
+	// Fields holds onto the fields of object and interface types.
+	Fields []*Field
+
+	// Interfaces holds onto the names of the interfaces implemented by object and interface
+	// types.
+	Interfaces []string
+
+	// PossibleTypes holds onto the names of the members of union types and, for interface
+	// types, the names of the types that implement them.
+	PossibleTypes []string
+
+	// EnumValues holds onto the values of enum types.
+	EnumValues []*EnumValue
+
+	// InputFields holds onto the fields of input object types.
+	InputFields []*InputValue
+}
+
+// Field returns the field of an object or interface type with the given name, or nil if there
+// is none.
+func (t *Type) Field(name string) *Field {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// InputField returns the field of an input object type with the given name, or nil if there is
+// none.
+func (t *Type) InputField(name string) *InputValue {
+	for _, f := range t.InputFields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// EnumValue returns the value of an enum type with the given name, or nil if there is none.
+func (t *Type) EnumValue(name string) *EnumValue {
+	for _, v := range t.EnumValues {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// IsInputType denotes whether or not values of the type can be passed as arguments, i.e. the
+// type is a scalar, enum, or input object type.
+func (t *Type) IsInputType() bool {
+	return t.Kind == KindScalar || t.Kind == KindEnum || t.Kind == KindInputObject
+}
+
+// IsComposite denotes whether or not fields can be selected on the type, i.e. the type is an
+// object, interface, or union type.
+func (t *Type) IsComposite() bool {
+	return t.Kind == KindObject || t.Kind == KindInterface || t.Kind == KindUnion
+}
+
+// Field is a field of an object or interface type.
+type Field struct {
+	Name        string
+	Description string
+	Args        []*InputValue
+	Type        *TypeRef
+
+	IsDeprecated      bool
+	DeprecationReason string
+}
+
+// Arg returns the argument of the field with the given name, or nil if there is none.
+func (f *Field) Arg(name string) *InputValue {
+	for _, arg := range f.Args {
+		if arg.Name == name {
+			return arg
+		}
+	}
+	return nil
+}
+
+// InputValue is an argument of a field or directive, or a field of an input object type.
+type InputValue struct {
+	Name        string
+	Description string
+	Type        *TypeRef
+
+	// DefaultValue is the default value of the input value as a GraphQL literal, e.g. 10 or
+	// "foo", or nil if it has none.
+	DefaultValue *string
+}
+
+// EnumValue is a value of an enum type.
+type EnumValue struct {
+	Name        string
+	Description string
+
+	IsDeprecated      bool
+	DeprecationReason string
+}
+
+// Directive is a directive that a schema supports.
+type Directive struct {
+	Name        string
+	Description string
+	Locations   []string
+	Args        []*InputValue
+
+	// IsRepeatable denotes whether or not the directive can be used more than once at a
+	// single location.
+	IsRepeatable bool
+}
+
+// TypeRef is a reference to a type, which is either a named type or a list or non-null
+// type wrapping another reference, e.g. [ID!]!.
+type TypeRef struct {
+	Kind Kind
+
+	// Name is the name of the referenced type, it's empty for lists and non-null types.
+	Name string
+
+	// OfType is the reference wrapped by lists and non-null types.
+	OfType *TypeRef
+}
+
+// Named returns a reference to the named type with the given name.
+func Named(name string) *TypeRef {
+	return &TypeRef{Name: name}
+}
+
+// ListOf returns a reference to a list of the given reference.
+func ListOf(t *TypeRef) *TypeRef {
+	return &TypeRef{Kind: KindList, OfType: t}
+}
+
+// NonNullOf returns a reference to the given reference made non-null.
+func NonNullOf(t *TypeRef) *TypeRef {
+	return &TypeRef{Kind: KindNonNull, OfType: t}
+}
+
+// ParseTypeRef parses a type reference written in the GraphQL syntax, e.g. [ID!]!. The kinds of
+// the named types it refers to aren't known, so they're left empty, see Schema.Resolve.
+func ParseTypeRef(s string) (*TypeRef, error) {
+	p := parser{lexer: newLexer(s)}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	t, err := p.typeRef()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return t, nil
+}
+
+// NonNull denotes whether or not the reference is to a non-null type.
+func (t *TypeRef) NonNull() bool {
+	return t.Kind == KindNonNull
+}
+
+// Nullable returns the reference without its outermost non-null modifier, if it has one.
+func (t *TypeRef) Nullable() *TypeRef {
+	if t.Kind == KindNonNull {
+		return t.OfType
+	}
+	return t
+}
+
+// IsList denotes whether or not the reference is to a list, regardless of whether the list is
+// non-null or not.
+func (t *TypeRef) IsList() bool {
+	return t.Nullable().Kind == KindList
+}
+
+// NamedType returns the name of the named type at the core of the reference, e.g. ID for [ID!]!.
+func (t *TypeRef) NamedType() string {
+	for t.OfType != nil {
+		t = t.OfType
+	}
+	return t.Name
+}
+
+// String returns the reference written in the GraphQL syntax, e.g. [ID!]!.
+func (t *TypeRef) String() string {
+	switch t.Kind {
+	case KindList:
+		return fmt.Sprintf("[%s]", t.OfType)
+	case KindNonNull:
+		return fmt.Sprintf("%s!", t.OfType)
+	default:
+		return t.Name
+	}
+}
+
+// Resolve fills in the kinds of the named types referred to throughout the schema, which are
+// unknown after parsing type references on their own. It returns an error listing the names of
+// the types that are referred to but not defined by the schema.
+func (s *Schema) Resolve() error {
+	kinds := make(map[string]Kind, len(s.Types))
+	for _, t := range s.Types {
+		kinds[t.Name] = t.Kind
+	}
+
+	undefined := make(map[string]bool)
+	var names []string
+
+	var resolve func(ref *TypeRef)
+	resolve = func(ref *TypeRef) {
+		if ref == nil {
+			return
+		}
+
+		if ref.OfType != nil {
+			resolve(ref.OfType)
+			return
+		}
+
+		kind, exists := kinds[ref.Name]
+		if !exists {
+			if !undefined[ref.Name] {
+				undefined[ref.Name] = true
+				names = append(names, ref.Name)
+			}
+			return
+		}
+		ref.Kind = kind
+	}
+
+	resolveValues := func(values []*InputValue) {
+		for _, v := range values {
+			resolve(v.Type)
+		}
+	}
+
+	for _, t := range s.Types {
+		for _, f := range t.Fields {
+			resolve(f.Type)
+			resolveValues(f.Args)
+		}
+		resolveValues(t.InputFields)
+	}
+
+	for _, d := range s.Directives {
+		resolveValues(d.Args)
+	}
+
+	if len(names) > 0 {
+		return fmt.Errorf("undefined types: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package schema
+
+import (
+	"testing"
+)
+
+// testSDL is a schema that exercises every kind of definition.
+const testSDL = `"""The schema of a test server."""
+schema {
+  query: Root
+  mutation: Mutations
+}
+
+"""Marks a field as cached."""
+directive @cached(
+  """How long to cache the field for, in seconds."""
+  ttl: Int = 60
+) repeatable on FIELD_DEFINITION | OBJECT
+
+"""A point in time."""
+scalar DateTime @specifiedBy(url: "https://tools.ietf.org/html/rfc3339")
+
+type Root {
+  """Looks up a user by its ID."""
+  user(id: ID!): User
+  users(first: Int = 10, after: String, filter: UserFilter = {role: ADMIN, names: ["a", "b"]}): [User!]!
+  node(id: ID!): Node
+  search(text: String!): [SearchResult]
+  legacyUser(id: ID!): User @deprecated
+}
+
+type Mutations {
+  updateUser(id: ID!, input: UserInput!): User
+}
+
+interface Node {
+  id: ID!
+}
+
+interface Entity implements Node {
+  id: ID!
+  createdAt: DateTime
+}
+
+"""
+A user of the system.
+
+Users can be admins.
+"""
+type User implements Node & Entity {
+  id: ID!
+  createdAt: DateTime
+  name: String
+  role: Role!
+  friends(first: Int): [User!]
+  nickname: String @deprecated(reason: "Use \"name\" instead.")
+}
+
+type Team implements Node {
+  id: ID!
+  members: [User!]!
+}
+
+union SearchResult = User | Team
+
+enum Role {
+  ADMIN
+  """A regular member."""
+  MEMBER
+  GUEST @deprecated(reason: "Guests were removed.")
+}
+
+input UserFilter {
+  role: Role
+  names: [String!]
+}
+
+input UserInput {
+  name: String
+  role: Role = MEMBER
+}
+`
+
+func TestParseTypeRef(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		Name          string
+		Input         string
+		ExpectedError bool
+	}{
+		{
+			Name:  "Named",
+			Input: "ID",
+		},
+		{
+			Name:  "NonNull",
+			Input: "ID!",
+		},
+		{
+			Name:  "List",
+			Input: "[ID]",
+		},
+		{
+			Name:  "Nested",
+			Input: "[[ID!]]!",
+		},
+		{
+			Name:          "Unterminated",
+			Input:         "[ID!",
+			ExpectedError: true,
+		},
+		{
+			Name:          "Trailing",
+			Input:         "ID! ID",
+			ExpectedError: true,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			ref, err := ParseTypeRef(test.Input)
+			if test.ExpectedError {
+				if err == nil {
+					t.Fatalf("expected error parsing %q, got %s", test.Input, ref)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error parsing %q: %v", test.Input, err)
+			}
+
+			if ref.String() != test.Input {
+				t.Errorf("expected %s, got %s", test.Input, ref)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestTypeRef(t *testing.T) {
+	t.Parallel()
+
+	ref, err := ParseTypeRef("[User!]!")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ref.NonNull() {
+		t.Error("expected non-null reference")
+	}
+
+	if !ref.IsList() {
+		t.Error("expected list reference")
+	}
+
+	if ref.Nullable().String() != "[User!]" {
+		t.Errorf("expected nullable reference to be [User!], got %s", ref.Nullable())
+	}
+
+	if ref.NamedType() != "User" {
+		t.Errorf("expected named type to be User, got %s", ref.NamedType())
+	}
+}
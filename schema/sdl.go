@@ -0,0 +1,639 @@
+package schema
+
+import (
+	"io"
+)
+
+// defaultDeprecationReason is the reason given by the @deprecated directive when none is passed
+// to it.
+const defaultDeprecationReason = "No longer supported"
+
+// builtinDirectives are the directives that every GraphQL schema has, which aren't written out
+// in SDL documents.
+const builtinDirectives = `
+"Directs the executor to include this field or fragment only when the ` + "`if`" + ` argument is true."
+directive @include("Included when true." if: Boolean!) on FIELD | FRAGMENT_SPREAD | INLINE_FRAGMENT
+
+"Directs the executor to skip this field or fragment when the ` + "`if`" + ` argument is true."
+directive @skip("Skipped when true." if: Boolean!) on FIELD | FRAGMENT_SPREAD | INLINE_FRAGMENT
+
+"Marks an element of a GraphQL schema as no longer supported."
+directive @deprecated(
+  "Explains why this element was deprecated, usually also including a suggestion for how to access supported similar data."
+  reason: String = "No longer supported"
+) on FIELD_DEFINITION | ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION | ENUM_VALUE
+
+"Exposes a URL that specifies the behavior of this scalar."
+directive @specifiedBy("The URL that specifies the behavior of this scalar." url: String!) on SCALAR
+`
+
+// isBuiltinDirective denotes whether or not the directive with the given name is one of the
+// builtin directives.
+func isBuiltinDirective(name string) bool {
+	switch name {
+	case "include", "skip", "deprecated", "specifiedBy":
+		return true
+	}
+	return false
+}
+
+// isBuiltinScalar denotes whether or not the type with the given name is one of the builtin
+// scalar types.
+func isBuiltinScalar(name string) bool {
+	for _, scalar := range builtinScalars {
+		if scalar == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadSDL reads a schema written in the GraphQL schema definition language from r, see
+// ParseSDL.
+func LoadSDL(r io.Reader) (*Schema, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSDL(string(b))
+}
+
+// ParseSDL parses a schema written in the GraphQL schema definition language, e.g.:
+//
+//	type Query {
+//	  user(id: ID!): User
+//	}
+//
+//	type User {
+//	  id: ID!
+//	  name: String
+//	}
+//
+// The builtin scalar types and directives are added to the schema unless the document defines
+// them itself, and the root operation types default to the types named Query, Mutation, and
+// Subscription if the document has no schema definition. Type extensions are merged into the
+// types they extend.
+func ParseSDL(src string) (*Schema, error) {
+	s, err := parseDocument(src)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range builtinScalars {
+		if s.Type(name) == nil {
+			s.Types = append(s.Types, &Type{Kind: KindScalar, Name: name})
+		}
+	}
+
+	builtin, err := parseDocument(builtinDirectives)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range builtin.Directives {
+		if s.Directive(d.Name) == nil {
+			s.Directives = append(s.Directives, d)
+		}
+	}
+
+	if s.QueryType == "" && s.MutationType == "" && s.SubscriptionType == "" {
+		for _, root := range []struct {
+			name string
+			dst  *string
+		}{
+			{"Query", &s.QueryType},
+			{"Mutation", &s.MutationType},
+			{"Subscription", &s.SubscriptionType},
+		} {
+			if t := s.Type(root.name); t != nil && t.Kind == KindObject {
+				*root.dst = root.name
+			}
+		}
+	}
+
+	// The possible types of interfaces aren't written out, they're the object types that
+	// implement them.
+	for _, t := range s.Types {
+		if t.Kind != KindObject {
+			continue
+		}
+
+		for _, name := range t.Interfaces {
+			if iface := s.Type(name); iface != nil && iface.Kind == KindInterface {
+				iface.PossibleTypes = append(iface.PossibleTypes, t.Name)
+			}
+		}
+	}
+
+	if err := s.Resolve(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// extension is a type or schema extension, which is applied once the whole document was parsed
+// since the definition it extends may come after it.
+type extension struct {
+	line int
+	typ  *Type
+
+	// schema denotes whether or not the extension extends the schema definition, in which
+	// case root holds onto the root operation types it adds.
+	schema bool
+	root   map[string]string
+}
+
+// parseDocument parses the type system definitions of an SDL document into a schema, as is.
+func parseDocument(src string) (*Schema, error) { //nolint:funlen,gocyclo
+	p := parser{lexer: newLexer(src)}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	var s Schema
+	var extensions []extension
+
+	for p.tok.kind != tokenEOF {
+		line := p.tok.line
+
+		description, err := p.description()
+		if err != nil {
+			return nil, err
+		}
+
+		extend := p.peekKeyword("extend")
+		if extend {
+			if description != "" {
+				return nil, p.errorf("unexpected description on extension")
+			}
+
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+
+		if p.tok.kind != tokenName {
+			return nil, p.errorf("expected definition, got %s", p.tok)
+		}
+
+		switch keyword := p.tok.value; keyword {
+		case "schema":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+
+			root, err := p.schemaDefinition()
+			if err != nil {
+				return nil, err
+			}
+
+			if extend {
+				extensions = append(extensions, extension{line: line, schema: true, root: root})
+				continue
+			}
+
+			s.Description = description
+			s.QueryType, s.MutationType, s.SubscriptionType = root["query"], root["mutation"], root["subscription"]
+		case "directive":
+			if extend {
+				return nil, p.errorf("unexpected directive extension")
+			}
+
+			d, err := p.directiveDefinition()
+			if err != nil {
+				return nil, err
+			}
+			d.Description = description
+
+			if s.Directive(d.Name) != nil {
+				return nil, p.errorf("directive @%s is defined more than once", d.Name)
+			}
+			s.Directives = append(s.Directives, d)
+		case "scalar", "type", "interface", "union", "enum", "input":
+			t, err := p.typeDefinition(keyword)
+			if err != nil {
+				return nil, err
+			}
+			t.Description = description
+
+			if extend {
+				extensions = append(extensions, extension{line: line, typ: t})
+				continue
+			}
+
+			if s.Type(t.Name) != nil {
+				return nil, p.errorf("type %s is defined more than once", t.Name)
+			}
+			s.Types = append(s.Types, t)
+		default:
+			return nil, p.errorf("unexpected %s", p.tok)
+		}
+	}
+
+	for _, ext := range extensions {
+		if err := s.extend(ext); err != nil {
+			return nil, err
+		}
+	}
+
+	return &s, nil
+}
+
+// extend applies ext to the definition it extends.
+func (s *Schema) extend(ext extension) error {
+	if ext.schema {
+		for operation, dst := range map[string]*string{
+			"query":        &s.QueryType,
+			"mutation":     &s.MutationType,
+			"subscription": &s.SubscriptionType,
+		} {
+			if name, exists := ext.root[operation]; exists {
+				if *dst != "" {
+					return errorAt(ext.line, "root %s type is defined more than once", operation)
+				}
+				*dst = name
+			}
+		}
+		return nil
+	}
+
+	t := s.Type(ext.typ.Name)
+	if t == nil {
+		return errorAt(ext.line, "cannot extend undefined type %s", ext.typ.Name)
+	}
+
+	if t.Kind != ext.typ.Kind {
+		return errorAt(ext.line, "cannot extend %s type %s with a %s extension", t.Kind, t.Name, ext.typ.Kind)
+	}
+
+	t.Fields = append(t.Fields, ext.typ.Fields...)
+	t.Interfaces = append(t.Interfaces, ext.typ.Interfaces...)
+	t.PossibleTypes = append(t.PossibleTypes, ext.typ.PossibleTypes...)
+	t.EnumValues = append(t.EnumValues, ext.typ.EnumValues...)
+	t.InputFields = append(t.InputFields, ext.typ.InputFields...)
+
+	if ext.typ.SpecifiedByURL != "" {
+		t.SpecifiedByURL = ext.typ.SpecifiedByURL
+	}
+
+	return nil
+}
+
+// errorAt returns an error that points at the given line of a document.
+func errorAt(line int, format string, args ...interface{}) error {
+	l := lexer{line: line}
+	return l.errorf(format, args...)
+}
+
+// schemaDefinition consumes the root operation types of a schema definition, after the schema
+// keyword.
+func (p *parser) schemaDefinition() (map[string]string, error) {
+	if _, err := p.directives(true); err != nil {
+		return nil, err
+	}
+
+	root := make(map[string]string)
+	if !p.peek("{") {
+		return root, nil
+	}
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	for !p.peek("}") {
+		operation, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		switch operation {
+		case "query", "mutation", "subscription":
+		default:
+			return nil, p.errorf("unknown operation type %q", operation)
+		}
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		root[operation] = name
+	}
+
+	return root, p.next()
+}
+
+// directiveDefinition consumes a directive definition, starting at the directive keyword.
+func (p *parser) directiveDefinition() (*Directive, error) {
+	if err := p.expectKeyword("directive"); err != nil {
+		return nil, err
+	}
+
+	if err := p.expect("@"); err != nil {
+		return nil, err
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	d := Directive{Name: name}
+
+	if d.Args, err = p.inputValues("(", ")"); err != nil {
+		return nil, err
+	}
+
+	if p.peekKeyword("repeatable") {
+		d.IsRepeatable = true
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.expectKeyword("on"); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.skip("|"); err != nil {
+		return nil, err
+	}
+
+	for {
+		location, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		d.Locations = append(d.Locations, location)
+
+		if ok, err := p.skip("|"); err != nil {
+			return nil, err
+		} else if !ok {
+			break
+		}
+	}
+
+	return &d, nil
+}
+
+// typeDefinition consumes the definition or extension of a named type, starting at the given
+// keyword.
+func (p *parser) typeDefinition(keyword string) (*Type, error) { //nolint:funlen,gocyclo
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	t := Type{Name: name}
+
+	switch keyword {
+	case "scalar":
+		t.Kind = KindScalar
+	case "type":
+		t.Kind = KindObject
+	case "interface":
+		t.Kind = KindInterface
+	case "union":
+		t.Kind = KindUnion
+	case "enum":
+		t.Kind = KindEnum
+	case "input":
+		t.Kind = KindInputObject
+	}
+
+	if (t.Kind == KindObject || t.Kind == KindInterface) && p.peekKeyword("implements") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		if _, err := p.skip("&"); err != nil {
+			return nil, err
+		}
+
+		for {
+			iface, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			t.Interfaces = append(t.Interfaces, iface)
+
+			if ok, err := p.skip("&"); err != nil {
+				return nil, err
+			} else if !ok {
+				break
+			}
+		}
+	}
+
+	directives, err := p.directives(true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range directives {
+		if d.Name != "specifiedBy" {
+			continue
+		}
+
+		if url := d.Argument("url"); url != nil && url.Kind == ValueString {
+			t.SpecifiedByURL = url.Raw
+		}
+	}
+
+	switch t.Kind {
+	case KindObject, KindInterface:
+		t.Fields, err = p.fieldDefinitions()
+	case KindUnion:
+		t.PossibleTypes, err = p.unionMembers()
+	case KindEnum:
+		t.EnumValues, err = p.enumValues()
+	case KindInputObject:
+		t.InputFields, err = p.inputValues("{", "}")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// fieldDefinitions consumes the field definitions of an object or interface type, if there are
+// any.
+func (p *parser) fieldDefinitions() ([]*Field, error) {
+	if ok, err := p.skip("{"); err != nil || !ok {
+		return nil, err
+	}
+
+	var fields []*Field
+	for !p.peek("}") {
+		description, err := p.description()
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		f := Field{Name: name, Description: description}
+
+		if f.Args, err = p.inputValues("(", ")"); err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		if f.Type, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+
+		directives, err := p.directives(true)
+		if err != nil {
+			return nil, err
+		}
+
+		f.IsDeprecated, f.DeprecationReason = deprecation(directives)
+
+		fields = append(fields, &f)
+	}
+
+	return fields, p.next()
+}
+
+// inputValues consumes argument or input field definitions enclosed by the given punctuators, if
+// there are any.
+func (p *parser) inputValues(start, end string) ([]*InputValue, error) {
+	if ok, err := p.skip(start); err != nil || !ok {
+		return nil, err
+	}
+
+	var values []*InputValue
+	for !p.peek(end) {
+		description, err := p.description()
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		v := InputValue{Name: name, Description: description}
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		if v.Type, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+
+		if ok, err := p.skip("="); err != nil {
+			return nil, err
+		} else if ok {
+			value, err := p.value(true)
+			if err != nil {
+				return nil, err
+			}
+
+			literal := value.String()
+			v.DefaultValue = &literal
+		}
+
+		if _, err := p.directives(true); err != nil {
+			return nil, err
+		}
+
+		values = append(values, &v)
+	}
+
+	return values, p.next()
+}
+
+// unionMembers consumes the members of a union type, if there are any.
+func (p *parser) unionMembers() ([]string, error) {
+	if ok, err := p.skip("="); err != nil || !ok {
+		return nil, err
+	}
+
+	if _, err := p.skip("|"); err != nil {
+		return nil, err
+	}
+
+	var members []string
+	for {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, name)
+
+		if ok, err := p.skip("|"); err != nil {
+			return nil, err
+		} else if !ok {
+			return members, nil
+		}
+	}
+}
+
+// enumValues consumes the values of an enum type, if there are any.
+func (p *parser) enumValues() ([]*EnumValue, error) {
+	if ok, err := p.skip("{"); err != nil || !ok {
+		return nil, err
+	}
+
+	var values []*EnumValue
+	for !p.peek("}") {
+		description, err := p.description()
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		v := EnumValue{Name: name, Description: description}
+
+		directives, err := p.directives(true)
+		if err != nil {
+			return nil, err
+		}
+
+		v.IsDeprecated, v.DeprecationReason = deprecation(directives)
+
+		values = append(values, &v)
+	}
+
+	return values, p.next()
+}
+
+// deprecation returns whether or not the given directives deprecate the definition they're
+// applied to, and why.
+func deprecation(directives []*AppliedDirective) (bool, string) {
+	for _, d := range directives {
+		if d.Name != "deprecated" {
+			continue
+		}
+
+		if reason := d.Argument("reason"); reason != nil && reason.Kind == ValueString {
+			return true, reason.Raw
+		}
+		return true, defaultDeprecationReason
+	}
+
+	return false, ""
+}
@@ -0,0 +1,233 @@
+package schema
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSDL(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	s, err := ParseSDL(testSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Description != "The schema of a test server." {
+		t.Errorf("unexpected schema description %q", s.Description)
+	}
+
+	if s.QueryType != "Root" || s.MutationType != "Mutations" || s.SubscriptionType != "" {
+		t.Errorf("unexpected root types %q, %q, and %q", s.QueryType, s.MutationType, s.SubscriptionType)
+	}
+
+	for _, name := range []string{"Int", "Float", "String", "Boolean", "ID"} {
+		if typ := s.Type(name); typ == nil || typ.Kind != KindScalar {
+			t.Errorf("expected builtin scalar %s", name)
+		}
+	}
+
+	for _, name := range []string{"include", "skip", "deprecated", "specifiedBy", "cached"} {
+		if s.Directive(name) == nil {
+			t.Errorf("expected directive @%s", name)
+		}
+	}
+
+	cached := s.Directive("cached")
+	if !cached.IsRepeatable || !reflect.DeepEqual(cached.Locations, []string{"FIELD_DEFINITION", "OBJECT"}) {
+		t.Errorf("unexpected directive @cached: %+v", cached)
+	}
+
+	if dateTime := s.Type("DateTime"); dateTime.SpecifiedByURL != "https://tools.ietf.org/html/rfc3339" {
+		t.Errorf("unexpected specifiedByURL %q", dateTime.SpecifiedByURL)
+	}
+
+	root := s.RootType("query")
+	users := root.Field("users")
+	if users.Type.String() != "[User!]!" || users.Type.NamedType() != "User" {
+		t.Errorf("unexpected type of users %s", users.Type)
+	}
+
+	if users.Type.OfType.OfType.OfType.Kind != KindObject {
+		t.Errorf("expected the kind of User to be resolved, got %q", users.Type.OfType.OfType.OfType.Kind)
+	}
+
+	if filter := users.Arg("filter"); filter.DefaultValue == nil || *filter.DefaultValue != `{role: ADMIN, names: ["a", "b"]}` {
+		t.Errorf("unexpected default value of filter %v", filter.DefaultValue)
+	}
+
+	if legacy := root.Field("legacyUser"); !legacy.IsDeprecated || legacy.DeprecationReason != defaultDeprecationReason {
+		t.Errorf("unexpected deprecation of legacyUser: %+v", legacy)
+	}
+
+	user := s.Type("User")
+	if nickname := user.Field("nickname"); !nickname.IsDeprecated || nickname.DeprecationReason != `Use "name" instead.` {
+		t.Errorf("unexpected deprecation of nickname: %+v", nickname)
+	}
+
+	if user.Description != "A user of the system.\n\nUsers can be admins." {
+		t.Errorf("unexpected description of User %q", user.Description)
+	}
+
+	if !reflect.DeepEqual(user.Interfaces, []string{"Node", "Entity"}) {
+		t.Errorf("unexpected interfaces of User %v", user.Interfaces)
+	}
+
+	if node := s.Type("Node"); !reflect.DeepEqual(node.PossibleTypes, []string{"User", "Team"}) {
+		t.Errorf("unexpected possible types of Node %v", node.PossibleTypes)
+	}
+
+	if result := s.Type("SearchResult"); !reflect.DeepEqual(result.PossibleTypes, []string{"User", "Team"}) {
+		t.Errorf("unexpected possible types of SearchResult %v", result.PossibleTypes)
+	}
+
+	if guest := s.Type("Role").EnumValue("GUEST"); !guest.IsDeprecated || guest.DeprecationReason != "Guests were removed." {
+		t.Errorf("unexpected deprecation of GUEST: %+v", guest)
+	}
+}
+
+func TestParseSDLExtensions(t *testing.T) {
+	t.Parallel()
+
+	s, err := ParseSDL(`
+extend type Query {
+  team: String
+}
+
+type Query {
+  user: String
+}
+
+extend enum Role { GUEST }
+
+enum Role { ADMIN }
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.QueryType != "Query" {
+		t.Errorf("expected query type to default to Query, got %q", s.QueryType)
+	}
+
+	if query := s.Type("Query"); query.Field("user") == nil || query.Field("team") == nil {
+		t.Errorf("expected extension to be merged into Query")
+	}
+
+	if role := s.Type("Role"); len(role.EnumValues) != 2 {
+		t.Errorf("expected extension to be merged into Role")
+	}
+}
+
+func TestParseSDLErrors(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		Name          string
+		Input         string
+		ExpectedError string
+	}{
+		{
+			Name:          "UndefinedType",
+			Input:         "type Query { user: User }",
+			ExpectedError: "undefined types: User",
+		},
+		{
+			Name:          "DuplicateType",
+			Input:         "type Query { a: Int }\n\ntype Query { b: Int }",
+			ExpectedError: "line 3: type Query is defined more than once",
+		},
+		{
+			Name:          "UnterminatedString",
+			Input:         "type Query {\n  \"description\n  a: Int\n}",
+			ExpectedError: "line 2: unterminated string",
+		},
+		{
+			Name:          "MissingType",
+			Input:         "type Query {\n  a\n}",
+			ExpectedError: `line 3: expected ":", got "}"`,
+		},
+		{
+			Name:          "UndefinedExtension",
+			Input:         "extend type Query { a: Int }",
+			ExpectedError: "line 1: cannot extend undefined type Query",
+		},
+		{
+			Name:          "VariableDefault",
+			Input:         "type Query { a(b: Int = $c): Int }",
+			ExpectedError: "line 1: unexpected variable in constant value",
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseSDL(test.Input)
+			if err == nil {
+				t.Fatalf("expected error %q, got nil", test.ExpectedError)
+			}
+
+			if err.Error() != test.ExpectedError {
+				t.Errorf("expected error %q, got %q", test.ExpectedError, err)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestWriteSDL(t *testing.T) {
+	t.Parallel()
+
+	s, err := ParseSDL(testSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := s.WriteSDL(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(testSDL, b.String()); diff != "" {
+		t.Errorf("expected SDL to be written as it was parsed (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteSDLBlankDescriptions(t *testing.T) {
+	t.Parallel()
+
+	input := `" "
+type Query {
+  "\n\t"
+  id: ID
+}
+`
+
+	s, err := ParseSDL(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := s.WriteSDL(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(input, b.String()); diff != "" {
+		t.Errorf("expected SDL to be written as it was parsed (-want +got):\n%s", diff)
+	}
+
+	written, err := ParseSDL(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(s, written); diff != "" {
+		t.Errorf("schema changed when written and parsed again (-want +got):\n%s", diff)
+	}
+}
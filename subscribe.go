@@ -0,0 +1,214 @@
+package goql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/getoutreach/gobox/pkg/events"
+	"github.com/getoutreach/gobox/pkg/log"
+	"github.com/gorilla/websocket"
+)
+
+// graphqlTransportWS is the WebSocket subprotocol implemented by the subscription client, see
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md for its specification.
+const graphqlTransportWS = "graphql-transport-ws"
+
+// subscriptionID is the id of the single subscription that is multiplexed over each connection.
+const subscriptionID = "1"
+
+// Message types of the graphql-transport-ws protocol.
+const (
+	msgConnectionInit = "connection_init"
+	msgConnectionAck  = "connection_ack"
+	msgPing           = "ping"
+	msgPong           = "pong"
+	msgSubscribe      = "subscribe"
+	msgNext           = "next"
+	msgError          = "error"
+	msgComplete       = "complete"
+)
+
+// SubscriptionHandler is the type of the function that receives each payload of a subscription.
+// data is a freshly allocated value of the same type as Operation.OperationType that the payload
+// was decoded into. If a non-nil error is returned the subscription is stopped and the error is
+// returned from Subscribe.
+type SubscriptionHandler func(data interface{}) error
+
+// message is the envelope of every message sent over a graphql-transport-ws connection.
+type message struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// websocketURL switches the scheme of a http(s) URL to ws(s). URLs with any other scheme are
+// returned unchanged.
+func websocketURL(clientURL string) string {
+	switch {
+	case strings.HasPrefix(clientURL, "https://"):
+		return "wss://" + strings.TrimPrefix(clientURL, "https://")
+	case strings.HasPrefix(clientURL, "http://"):
+		return "ws://" + strings.TrimPrefix(clientURL, "http://")
+	default:
+		return clientURL
+	}
+}
+
+// doSubscription marshals operation into a subscription, establishes a graphql-transport-ws
+// connection to the GraphQL server configured in the receiver, and feeds each payload that
+// the server sends into handler until the subscription ends.
+func (c *Client) doSubscription(ctx context.Context, operation *Operation, headers http.Header, //nolint:funlen
+	handler SubscriptionHandler) error {
+	rt := reflect.TypeOf(operation.OperationType)
+	if rt == nil || rt.Kind() != reflect.Ptr {
+		return fmt.Errorf("subscription operation type must be passed by reference, got %T", operation.OperationType)
+	}
+
+	queryStr, err := MarshalSubscriptionWithOptions(operation.OperationType, operation.Fields, c.marshalOpts...)
+	if err != nil {
+		return err
+	}
+
+	// The name of the operation was already validated while marshaling it.
+	name, _ := operationName(operation.OperationType) //nolint:errcheck
+
+	payload, err := json.Marshal(request{ //nolint:gocritic
+		Query:         queryStr,
+		OperationName: name,
+		Variables:     operation.Variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	dialer := websocket.Dialer{
+		Proxy:        http.ProxyFromEnvironment,
+		Subprotocols: []string{graphqlTransportWS},
+	}
+
+	conn, resp, err := dialer.DialContext(ctx, c.subscriptionURL, headers)
+	if err != nil {
+		return fmt.Errorf("establish subscription connection: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Closing the connection is the only way to interrupt a blocked read, so do that as soon as
+	// the context is done.
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close() //nolint:errcheck // Why: the read loop reports the failure
+	})
+	defer stop()
+
+	defer func() {
+		if err := conn.Close(); err != nil && ctx.Err() == nil {
+			log.Error(ctx, "close subscription connection", events.NewErrorInfo(err))
+		}
+	}()
+
+	if err := c.initSubscription(conn); err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	if err := conn.WriteJSON(message{ID: subscriptionID, Type: msgSubscribe, Payload: payload}); err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	for {
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil {
+			return ctxErr(ctx, err)
+		}
+
+		switch msg.Type {
+		case msgPing:
+			if err := conn.WriteJSON(message{Type: msgPong}); err != nil {
+				return ctxErr(ctx, err)
+			}
+		case msgNext:
+			var gqlResp Response
+			if err := json.Unmarshal(msg.Payload, &gqlResp); err != nil {
+				return err
+			}
+
+			if len(gqlResp.Errors) > 0 {
+				completeSubscription(conn)
+				return c.errorMapper(http.StatusOK, gqlResp.Errors)
+			}
+
+			data := reflect.New(rt.Elem()).Interface()
+			if err := decode(gqlResp.Data, data); err != nil {
+				completeSubscription(conn)
+				return err
+			}
+
+			if err := handler(data); err != nil {
+				completeSubscription(conn)
+				return err
+			}
+		case msgError:
+			var errs Errors
+			if err := json.Unmarshal(msg.Payload, &errs); err != nil {
+				return err
+			}
+			return c.errorMapper(http.StatusOK, errs)
+		case msgComplete:
+			return nil
+		default:
+			// Messages of any other type (e.g. pong) are not of interest to the client.
+		}
+	}
+}
+
+// initSubscription performs the connection_init/connection_ack handshake on a freshly established
+// graphql-transport-ws connection.
+func (c *Client) initSubscription(conn *websocket.Conn) error {
+	init := message{Type: msgConnectionInit}
+	if c.subscriptionInitPayload != nil {
+		b, err := json.Marshal(c.subscriptionInitPayload)
+		if err != nil {
+			return err
+		}
+		init.Payload = b
+	}
+
+	if err := conn.WriteJSON(init); err != nil {
+		return err
+	}
+
+	for {
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil {
+			return err
+		}
+
+		switch msg.Type {
+		case msgConnectionAck:
+			return nil
+		case msgPing:
+			if err := conn.WriteJSON(message{Type: msgPong}); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected message of type %q received before connection_ack", msg.Type)
+		}
+	}
+}
+
+// completeSubscription tells the server that the client is no longer interested in the
+// subscription. The connection is closed right after, so failures are ignored.
+func completeSubscription(conn *websocket.Conn) {
+	conn.WriteJSON(message{ID: subscriptionID, Type: msgComplete}) //nolint:errcheck
+}
+
+// ctxErr returns the error of ctx if it is done, since in that case err is merely the result
+// of the connection being closed underneath a read or write. Otherwise err is returned.
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
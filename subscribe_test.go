@@ -0,0 +1,39 @@
+package goql
+
+import "testing"
+
+// TestWebsocketURL tests the websocketURL function.
+func TestWebsocketURL(t *testing.T) {
+	tt := []struct {
+		Name           string
+		Input          string
+		ExpectedOutput string
+	}{
+		{
+			Name:           "HTTP",
+			Input:          "http://localhost:3403/graphql",
+			ExpectedOutput: "ws://localhost:3403/graphql",
+		},
+		{
+			Name:           "HTTPS",
+			Input:          "https://example.com/graphql",
+			ExpectedOutput: "wss://example.com/graphql",
+		},
+		{
+			Name:           "AlreadyWebSocket",
+			Input:          "wss://example.com/graphql",
+			ExpectedOutput: "wss://example.com/graphql",
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			if e, a := test.ExpectedOutput, websocketURL(test.Input); e != a {
+				t.Errorf("expected websocket url to be \"%s\", got \"%s\"", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
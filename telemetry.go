@@ -0,0 +1,207 @@
+package goql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/getoutreach/goql/schema"
+)
+
+// instrumentationName is the name of the tracer and meter that the client records telemetry with.
+const instrumentationName = "github.com/getoutreach/goql"
+
+// documentHashKey is the attribute key of the SHA-256 hash of the GraphQL document of an
+// operation, which identifies the operation without recording the document, let alone the
+// variables, on every span.
+const documentHashKey = attribute.Key("graphql.document.hash")
+
+// Values of the error.type attribute that aren't HTTP status codes.
+const (
+	// errorTypeGraphQL is the error.type of operations that returned errors in the GraphQL response.
+	errorTypeGraphQL = "graphql"
+
+	// errorTypeOther is the error.type of operations that failed for any other reason, e.g. a
+	// network error.
+	errorTypeOther = "_OTHER"
+)
+
+// telemetry holds onto the tracer and the instruments that the client records telemetry with.
+type telemetry struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	duration     metric.Float64Histogram
+	requestSize  metric.Int64Histogram
+	responseSize metric.Int64Histogram
+	errors       metric.Int64Counter
+}
+
+// newTelemetry creates the tracer and instruments of the client from the given providers. Nil
+// providers default to the global ones registered with the otel package.
+func newTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) *telemetry {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+
+	meter := mp.Meter(instrumentationName)
+
+	// Failing to create an instrument is reported to the global error handler, the instrument
+	// returned along with the error is still usable.
+	t := telemetry{
+		tracer:     tp.Tracer(instrumentationName),
+		propagator: otel.GetTextMapPropagator(),
+	}
+
+	var err error
+	if t.duration, err = meter.Float64Histogram("goql.client.operation.duration",
+		metric.WithDescription("Duration of GraphQL operations performed by the client, including retries."),
+		metric.WithUnit("s"),
+	); err != nil {
+		otel.Handle(err)
+	}
+
+	if t.requestSize, err = meter.Int64Histogram("goql.client.request.size",
+		metric.WithDescription("Size of the bodies of GraphQL requests sent by the client."),
+		metric.WithUnit("By"),
+	); err != nil {
+		otel.Handle(err)
+	}
+
+	if t.responseSize, err = meter.Int64Histogram("goql.client.response.size",
+		metric.WithDescription("Size of the bodies of GraphQL responses received by the client."),
+		metric.WithUnit("By"),
+	); err != nil {
+		otel.Handle(err)
+	}
+
+	if t.errors, err = meter.Int64Counter("goql.client.operation.errors",
+		metric.WithDescription("Number of GraphQL operations performed by the client that failed."),
+		metric.WithUnit("{operation}"),
+	); err != nil {
+		otel.Handle(err)
+	}
+
+	return &t
+}
+
+// operationType returns the type of the operation named name in the document query, as a
+// string, which is either "query", "mutation", or "subscription". The first operation of the
+// document is used if none of them is named name, e.g. if name is empty. The query shorthand,
+// e.g. "{ user { id } }", is a query. The document is parsed to find the operation, since it
+// may start with comments or fragment definitions, and documents that fail to parse are told
+// apart by how they start instead.
+func operationType(query, name string) string {
+	if doc, err := schema.ParseQuery(query); err == nil && len(doc.Operations) > 0 {
+		for _, op := range doc.Operations {
+			if op.Name == name {
+				return op.Operation
+			}
+		}
+		return doc.Operations[0].Operation
+	}
+
+	query = strings.TrimSpace(query)
+	switch {
+	case strings.HasPrefix(query, "mutation"):
+		return "mutation"
+	case strings.HasPrefix(query, "subscription"):
+		return "subscription"
+	default:
+		return "query"
+	}
+}
+
+// attributes returns the attributes that identify the operation of req on metrics.
+func (t *telemetry) attributes(req *Request) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{semconv.GraphqlOperationTypeKey.String(req.operationType())}
+	if req.OperationName != "" {
+		attrs = append(attrs, semconv.GraphqlOperationName(req.OperationName))
+	}
+	return attrs
+}
+
+// middleware is the Middleware that traces the operations performed by the client and records
+// their duration and errors. It is the outermost middleware of every client.
+func (t *telemetry) middleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (*Response, error) {
+		attrs := t.attributes(req)
+
+		// Span names are the operation type followed by the operation name, if any, as per the
+		// semantic conventions of GraphQL.
+		name := req.operationType()
+		if req.OperationName != "" {
+			name += " " + req.OperationName
+		}
+
+		ctx, span := t.tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...),
+		)
+		defer span.End()
+
+		// Only hash the document of operations that are actually traced.
+		if span.IsRecording() {
+			span.SetAttributes(documentHashKey.String(documentHash(req.Query)))
+		}
+
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+		t.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+		start := time.Now()
+		resp, err := next(ctx, req)
+		if resp == nil && err == nil {
+			err = errNoResponse
+		}
+
+		var errorType string
+		switch {
+		case err != nil:
+			errorType = errorTypeOther
+
+			var httpErr *HTTPError
+			if errors.As(err, &httpErr) {
+				errorType = strconv.Itoa(httpErr.StatusCode)
+				span.SetAttributes(semconv.HTTPResponseStatusCode(httpErr.StatusCode))
+			}
+
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		case len(resp.Errors) > 0:
+			errorType = errorTypeGraphQL
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+			for i := range resp.Errors {
+				span.RecordError(&resp.Errors[i])
+			}
+			span.SetStatus(codes.Error, resp.Errors.Error())
+		default:
+			span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+		}
+
+		if errorType != "" {
+			attrs = append(attrs, semconv.ErrorTypeKey.String(errorType))
+			t.errors.Add(ctx, 1, metric.WithAttributes(attrs...))
+		}
+		t.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+
+		return resp, err
+	}
+}
@@ -0,0 +1,198 @@
+package goql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestOperationType tests the operationType function.
+func TestOperationType(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		Name           string
+		Input          string
+		OperationName  string
+		ExpectedOutput string
+	}{
+		{
+			Name:           "Shorthand",
+			Input:          "{ user { id } }",
+			ExpectedOutput: "query",
+		},
+		{
+			Name:           "Query",
+			Input:          "query GetUser { user { id } }",
+			ExpectedOutput: "query",
+		},
+		{
+			Name:           "Mutation",
+			Input:          "\n mutation { createUser { id } }",
+			ExpectedOutput: "mutation",
+		},
+		{
+			Name:           "Subscription",
+			Input:          "subscription { userCreated { id } }",
+			ExpectedOutput: "subscription",
+		},
+		{
+			Name:           "LeadingComment",
+			Input:          "# Deletes a user.\nmutation { deleteUser { id } }",
+			ExpectedOutput: "mutation",
+		},
+		{
+			Name:           "LeadingFragment",
+			Input:          "fragment F on User { id } mutation { deleteUser { ...F } }",
+			ExpectedOutput: "mutation",
+		},
+		{
+			Name:           "Named",
+			Input:          "query GetUser { user { id } } mutation DeleteUser { deleteUser { id } }",
+			OperationName:  "DeleteUser",
+			ExpectedOutput: "mutation",
+		},
+		{
+			Name:           "Invalid",
+			Input:          "mutation { deleteUser { id }",
+			ExpectedOutput: "mutation",
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			if e, a := test.ExpectedOutput, operationType(test.Input, test.OperationName); e != a {
+				t.Errorf("expected operation type to be %q, got %q", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
+
+// TestTelemetry tests that operations performed through the client are traced and that their
+// metrics are recorded.
+func TestTelemetry(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		Name              string
+		Status            int
+		Body              string
+		ExpectedStatus    codes.Code
+		ExpectedErrorType string
+	}{
+		{
+			Name:           "Success",
+			Status:         http.StatusOK,
+			Body:           `{"data":{"user":{"id":"1"}}}`,
+			ExpectedStatus: codes.Unset,
+		},
+		{
+			Name:              "GraphQLErrors",
+			Status:            http.StatusOK,
+			Body:              `{"data":null,"errors":[{"message":"not found"}]}`,
+			ExpectedStatus:    codes.Error,
+			ExpectedErrorType: errorTypeGraphQL,
+		},
+		{
+			Name:              "HTTPError",
+			Status:            http.StatusBadGateway,
+			Body:              "<html>502 Bad Gateway</html>",
+			ExpectedStatus:    codes.Error,
+			ExpectedErrorType: "502",
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(test.Status)
+				io.WriteString(w, test.Body) //nolint:errcheck
+			}))
+			t.Cleanup(ts.Close)
+
+			spans := tracetest.NewSpanRecorder()
+			reader := sdkmetric.NewManualReader()
+
+			client := NewClient(ts.URL, ClientOptions{
+				TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+				MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+			})
+
+			query := "query GetUser($id: ID!) { user(id: $id) { id } }"
+			client.CustomOperation(context.Background(), query, map[string]interface{}{"id": "1"}, nil) //nolint:errcheck
+
+			ended := spans.Ended()
+			if len(ended) != 1 {
+				t.Fatalf("expected 1 span, got %d", len(ended))
+			}
+			span := ended[0]
+
+			if e, a := "query", span.Name(); e != a {
+				t.Errorf("expected span name to be %q, got %q", e, a)
+			}
+
+			if e, a := trace.SpanKindClient, span.SpanKind(); e != a {
+				t.Errorf("expected span kind to be %s, got %s", e, a)
+			}
+
+			if e, a := test.ExpectedStatus, span.Status().Code; e != a {
+				t.Errorf("expected span status to be %s, got %s", e, a)
+			}
+
+			attrs := attribute.NewSet(span.Attributes()...)
+			hash, _ := attrs.Value(documentHashKey)
+			if e, a := documentHash(query), hash.AsString(); e != a {
+				t.Errorf("expected document hash to be %q, got %q", e, a)
+			}
+
+			status, _ := attrs.Value("http.response.status_code")
+			if e, a := int64(test.Status), status.AsInt64(); e != a {
+				t.Errorf("expected status code attribute to be %d, got %d", e, a)
+			}
+
+			var rm metricdata.ResourceMetrics
+			if err := reader.Collect(context.Background(), &rm); err != nil {
+				t.Fatalf("error collecting metrics: %v", err)
+			}
+
+			var errorType string
+			metrics := make(map[string]bool)
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					metrics[m.Name] = true
+
+					if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "goql.client.operation.errors" {
+						value, _ := sum.DataPoints[0].Attributes.Value("error.type")
+						errorType = value.AsString()
+					}
+				}
+			}
+
+			for _, name := range []string{"goql.client.operation.duration", "goql.client.request.size"} {
+				if !metrics[name] {
+					t.Errorf("expected metric %s to be recorded", name)
+				}
+			}
+
+			if e, a := test.ExpectedErrorType, errorType; e != a {
+				t.Errorf("expected error type to be %q, got %q", e, a)
+			}
+		}
+		t.Run(test.Name, fn)
+	}
+}
@@ -60,21 +60,24 @@ func TestStack(t *testing.T) {
 		Decl: declaration{
 			Name:  "user",
 			Alias: "",
-			Tokens: []token{
+			Arguments: arguments{
 				{
-					Kind: "String!",
 					Name: "name",
-					Arg:  "name",
+					Value: value{
+						Kind: valueVariable,
+						Variable: token{
+							Kind: "String!",
+							Arg:  "name",
+						},
+					},
 				},
 			},
-			Template: "getUser",
 		},
 		Directives: []directive{
 			{
 				Type: "include",
 				Token: token{
 					Kind: "Boolean!",
-					Name: "ifAdmin",
 					Arg:  "ifAdmin",
 				},
 				Template: "",
@@ -100,8 +103,8 @@ func TestStack(t *testing.T) {
 		t.Errorf("expected length to be %d, got %d", e, a)
 	}
 
-	if e, a := f.Decl.Template, st.top().Decl.Template; e != a {
-		t.Errorf("expected declaration template on top to be %s, got %s", e, a)
+	if e, a := f.Decl.Name, st.top().Decl.Name; e != a {
+		t.Errorf("expected declaration name on top to be %s, got %s", e, a)
 	}
 
 	for i := 0; i < expectedLength-1; i++ {
@@ -113,13 +116,13 @@ func TestStack(t *testing.T) {
 		t.Errorf("expected length to be %d, got %d", e, a)
 	}
 
-	newDeclTemplate := "getUser2"
+	newDeclName := "user2"
 	st.apply(func(f *field) {
-		f.Decl.Template = newDeclTemplate
+		f.Decl.Name = newDeclName
 	})
 
-	if e, a := newDeclTemplate, st.top().Decl.Template; e != a {
-		t.Errorf("expected declaration template on top to be %s, got %s", e, a)
+	if e, a := newDeclName, st.top().Decl.Name; e != a {
+		t.Errorf("expected declaration name on top to be %s, got %s", e, a)
 	}
 }
 
@@ -130,21 +133,24 @@ func BenchmarkStack(b *testing.B) {
 		Decl: declaration{
 			Name:  "user",
 			Alias: "",
-			Tokens: []token{
+			Arguments: arguments{
 				{
-					Kind: "String!",
 					Name: "name",
-					Arg:  "name",
+					Value: value{
+						Kind: valueVariable,
+						Variable: token{
+							Kind: "String!",
+							Arg:  "name",
+						},
+					},
 				},
 			},
-			Template: "getUser",
 		},
 		Directives: []directive{
 			{
 				Type: "include",
 				Token: token{
 					Kind: "Boolean!",
-					Name: "ifAdmin",
 					Arg:  "ifAdmin",
 				},
 				Template: "",
@@ -158,7 +164,7 @@ func BenchmarkStack(b *testing.B) {
 		st.push(&f)
 		_ = st.top()
 		st.apply(func(f *field) {
-			f.Decl.Template = "getUser2"
+			f.Decl.Name = "user2"
 		})
 		_ = st.pop()
 	}
@@ -0,0 +1,529 @@
+package goql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/getoutreach/goql/schema"
+)
+
+// SchemaError is a problem found in an operation when validating it against a schema.
+type SchemaError struct {
+	// Path is the path of the Go struct field the problem was found at, starting at the name of
+	// the type of the operation, e.g. GetUser.User.Name.
+	Path string
+
+	// Message describes the problem.
+	Message string
+}
+
+// Error implements the error interface for the SchemaError type.
+func (e *SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// SchemaErrors is the error returned by ValidateQuery, ValidateMutation, and
+// ValidateSubscription, which holds onto every problem found in the operation, in the order of
+// the struct fields they were found at.
+type SchemaErrors []SchemaError
+
+// Error implements the error interface for the SchemaErrors type.
+func (e SchemaErrors) Error() string {
+	errs := make([]string, 0, len(e))
+	for i := range e {
+		errs = append(errs, e[i].Error())
+	}
+	return strings.Join(errs, ", ")
+}
+
+// ValidateQuery checks the struct type of q, as it would be marshaled by MarshalQuery, against
+// the schema s, which is e.g. loaded with schema.Load or returned by Client.Introspect. This
+// catches mistakes that GraphQL servers would otherwise only report at runtime:
+//
+//   - fields that don't exist on the type they're selected on;
+//   - arguments that the fields and directives they're passed to don't have;
+//   - variables whose types aren't compatible with the arguments they're passed as;
+//   - non-null arguments without a default value that aren't passed;
+//   - Go types that can't hold the data of the fields they're decoded from, e.g. a non-pointer
+//     Go type for a nullable field or an int for a String field.
+//
+// A nil error is returned if the operation is valid, SchemaErrors holding onto every problem
+// found otherwise.
+func ValidateQuery(s *schema.Schema, q interface{}, opts ...marshalOption) error {
+	return validate(s, "query", q, opts...)
+}
+
+// ValidateMutation is the equivalent of ValidateQuery for mutations, see MarshalMutation.
+func ValidateMutation(s *schema.Schema, q interface{}, opts ...marshalOption) error {
+	return validate(s, "mutation", q, opts...)
+}
+
+// ValidateSubscription is the equivalent of ValidateQuery for subscriptions, see
+// MarshalSubscription.
+func ValidateSubscription(s *schema.Schema, q interface{}, opts ...marshalOption) error {
+	return validate(s, "subscription", q, opts...)
+}
+
+// validate checks the operation q of the given type ("query", "mutation", or "subscription")
+// against the schema s.
+func validate(s *schema.Schema, operationType string, q interface{}, opts ...marshalOption) error {
+	opt := optStruct{tp: parseTag}
+	for _, o := range opts {
+		if o != nil {
+			o(&opt)
+		}
+	}
+
+	v := validator{schema: s, tagParse: opt.tp}
+
+	root := s.RootType(operationType)
+	if root == nil {
+		return SchemaErrors{{Message: fmt.Sprintf("schema doesn't support %s operations", operationType)}}
+	}
+
+	if err := walk(q, v.visit(root)); err != nil {
+		return err
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// validator validates an operation against a schema while walking through its struct type.
+type validator struct {
+	schema   *schema.Schema
+	tagParse tagParser
+
+	// frames holds onto a frame for each of the nodes being visited, from the root node to the
+	// current one.
+	frames []validationFrame
+	errs   SchemaErrors
+}
+
+// validationFrame is the state of a node being visited by a validator.
+type validationFrame struct {
+	// path is the path of the Go struct field of the node.
+	path string
+
+	// selection is the type that the children of the node are selected on. It's nil if the
+	// children of the node aren't validated, because the node is a leaf or has a problem of its
+	// own.
+	selection *schema.Type
+
+	// leaf is the type of the node if it's a scalar or enum type, which has no fields to select.
+	leaf *schema.Type
+}
+
+// errorf records a problem at the struct field with the given path.
+func (v *validator) errorf(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// visit returns the visit function that validates the nodes of an operation whose root fields
+// are selected on root.
+func (v *validator) visit(root *schema.Type) visit {
+	return func(n *node) error {
+		if n == nil {
+			v.frames = v.frames[:len(v.frames)-1]
+			return nil
+		}
+
+		if len(v.frames) == 0 {
+			v.frames = append(v.frames, validationFrame{path: n.Name, selection: root})
+			return nil
+		}
+
+		parent := v.frames[len(v.frames)-1]
+		frame := validationFrame{path: n.Name}
+		if parent.path != "" {
+			frame.path = parent.path + "." + n.Name
+		}
+
+		switch {
+		case parent.leaf != nil:
+			// The problem is reported once, for the first child of the leaf.
+			v.errorf(parent.path, "cannot select fields on leaf type %s", parent.leaf.Name)
+			v.frames[len(v.frames)-1].leaf = nil
+		case parent.selection != nil:
+			v.field(n, parent.selection, &frame)
+		}
+
+		v.frames = append(v.frames, frame)
+		return nil
+	}
+}
+
+// field validates the node n, which is selected on the type parent, and sets the type that its
+// children are selected on in frame.
+func (v *validator) field(n *node, parent *schema.Type, frame *validationFrame) { //nolint:funlen
+	f, err := v.tagParse(n.Tag)
+	if errors.Is(err, errSkipFieldFromTag) {
+		return
+	} else if err != nil {
+		v.errorf(frame.path, "%v", err)
+		return
+	}
+
+	_, on, isNamedFragment, err := namedFragment(n.Type)
+	if err != nil {
+		v.errorf(frame.path, "%v", err)
+		return
+	}
+
+	// Inline fragments and embedded named fragments that are spread in place select their
+	// children on their type condition, which needs to apply to the parent type.
+	spreadInPlace := isNamedFragment && n.Anonymous && f.Decl.Name == "" && f.Decl.Alias == "" && !f.isFragment()
+	if f.isFragment() || spreadInPlace {
+		condition := f.Decl.TypeCondition
+		location := "INLINE_FRAGMENT"
+		if spreadInPlace {
+			condition, location = on, "FRAGMENT_SPREAD"
+		}
+
+		v.directives(frame.path, location, f.Directives)
+		frame.selection = v.typeCondition(frame.path, parent, condition)
+		return
+	}
+
+	if f.Decl.Name == "" {
+		f.Decl.Name = toLowerCamelCase(n.Name)
+	}
+
+	var def *schema.Field
+	if f.Decl.Name == typenameField {
+		def = &schema.Field{Name: typenameField, Type: schema.NonNullOf(schema.Named("String"))}
+	} else if def = parent.Field(f.Decl.Name); def == nil {
+		v.errorf(frame.path, "unknown field %q on type %s", f.Decl.Name, parent.Name)
+		return
+	}
+
+	owner := fmt.Sprintf("field %s.%s", parent.Name, def.Name)
+	v.arguments(frame.path, owner, "argument", def.Args, f.Decl.Arguments)
+	v.directives(frame.path, "FIELD", f.Directives)
+
+	t := v.schema.Type(def.Type.NamedType())
+	if t == nil {
+		v.errorf(frame.path, "unknown type %s of %s", def.Type.NamedType(), owner)
+		return
+	}
+
+	held := n.Declared == nil || v.holds(n.Declared, def.Type)
+	if !held {
+		hint := ""
+		if !def.Type.NonNull() && !nullable(n.Declared) {
+			hint = ", which can be null, use a pointer"
+		}
+		v.errorf(frame.path, "Go type %s cannot hold GraphQL type %s of %s%s", n.Declared, def.Type, owner, hint)
+	}
+
+	if !t.IsComposite() {
+		// Go types that can't hold the field were already reported, their fields aren't.
+		if held {
+			frame.leaf = t
+		}
+		return
+	}
+
+	frame.selection = t
+
+	// Fields of a named fragment type select a spread of it, on its type condition.
+	if isNamedFragment {
+		frame.selection = v.typeCondition(frame.path, t, on)
+	}
+}
+
+// typeCondition returns the type named condition that a fragment on the type parent selects
+// its fields on, or nil if the type doesn't exist or the fragment can never apply to parent.
+func (v *validator) typeCondition(path string, parent *schema.Type, condition string) *schema.Type {
+	t := v.schema.Type(condition)
+	switch {
+	case t == nil:
+		v.errorf(path, "unknown type %s of fragment", condition)
+		return nil
+	case !t.IsComposite():
+		v.errorf(path, "fragment cannot be on %s, which isn't an object, interface, or union type", condition)
+		return nil
+	case !v.overlap(parent, t):
+		v.errorf(path, "fragment on %s can never apply to type %s", condition, parent.Name)
+		return nil
+	}
+	return t
+}
+
+// overlap denotes whether or not there is an object type that is both of type x and y.
+func (v *validator) overlap(x, y *schema.Type) bool {
+	possible := func(t *schema.Type) []string {
+		if t.Kind == schema.KindObject {
+			return []string{t.Name}
+		}
+		return t.PossibleTypes
+	}
+
+	for _, a := range possible(x) {
+		for _, b := range possible(y) {
+			if a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// directives validates the directives applied at the given location, e.g. FIELD.
+func (v *validator) directives(path, location string, directives []directive) {
+	for i := range directives {
+		d := &directives[i]
+
+		def := v.schema.Directive(string(d.Type))
+		if def == nil {
+			v.errorf(path, "unknown directive @%s", d.Type)
+			continue
+		}
+
+		allowed := false
+		for _, l := range def.Locations {
+			allowed = allowed || l == location
+		}
+
+		if !allowed {
+			v.errorf(path, "directive @%s cannot be used at %s", d.Type, location)
+		}
+
+		// The argument of skip and include is always rendered as a Boolean! variable.
+		args := d.Arguments
+		if d.Type == directiveInclude || d.Type == directiveSkip {
+			args = arguments{{Name: "if", Value: value{Kind: valueVariable, Variable: d.Token}}}
+			if (d.Token == token{}) {
+				args[0].Value = value{Kind: valueLiteral, Literal: d.Template}
+			}
+		}
+
+		v.arguments(path, "directive @"+def.Name, "argument", def.Args, args)
+	}
+}
+
+// arguments validates the arguments passed to a field or directive, or the fields of an input
+// object, owner, whose definitions are defs. member is what they're called in messages, i.e.
+// "argument" or "field".
+func (v *validator) arguments(path, owner, member string, defs []*schema.InputValue, args arguments) {
+	passed := make(map[string]bool, len(args))
+	for i := range args {
+		passed[args[i].Name] = true
+
+		def := findInputValue(defs, args[i].Name)
+		if def == nil {
+			v.errorf(path, "unknown %s %q of %s", member, args[i].Name, owner)
+			continue
+		}
+
+		v.value(path, fmt.Sprintf("%s %q of %s", member, def.Name, owner), def.Type, def.DefaultValue != nil, &args[i].Value)
+	}
+
+	for _, def := range defs {
+		if !passed[def.Name] && def.Type.NonNull() && def.DefaultValue == nil {
+			v.errorf(path, "missing %s %q of type %s of %s", member, def.Name, def.Type, owner)
+		}
+	}
+}
+
+// findInputValue returns the argument or input field with the given name, or nil if there is
+// none.
+func findInputValue(values []*schema.InputValue, name string) *schema.InputValue {
+	for _, v := range values {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// value validates the value val passed as the argument or input field where of type t, which
+// may be omitted if hasDefault is true.
+func (v *validator) value(path, where string, t *schema.TypeRef, hasDefault bool, val *value) { //nolint:funlen,gocyclo
+	switch val.Kind {
+	case valueVariable:
+		vt, err := schema.ParseTypeRef(val.Variable.Kind)
+		if err != nil {
+			v.errorf(path, "invalid type %s of variable $%s: %v", val.Variable.Kind, val.Variable.Arg, err)
+			return
+		}
+
+		if named := v.schema.Type(vt.NamedType()); named == nil || !named.IsInputType() {
+			v.errorf(path, "variable $%s is of type %s, which isn't an input type", val.Variable.Arg, vt)
+			return
+		}
+
+		// Nullable variables can be passed as non-null arguments if either of them has a
+		// default value.
+		location := t
+		if t.NonNull() && !vt.NonNull() && (hasDefault || val.Variable.Default != "") {
+			location = t.Nullable()
+		}
+
+		if !compatible(vt, location) {
+			v.errorf(path, "variable $%s of type %s cannot be passed as %s of type %s", val.Variable.Arg, vt, where, t)
+		}
+	case valueList:
+		if !t.IsList() {
+			v.errorf(path, "list cannot be passed as %s of type %s", where, t)
+			return
+		}
+
+		for i := range val.Items {
+			v.value(path, where, t.Nullable().OfType, false, &val.Items[i])
+		}
+	case valueObject:
+		if t.IsList() {
+			// A single value is coerced into a list of it.
+			v.value(path, where, t.Nullable().OfType, false, val)
+			return
+		}
+
+		named := v.schema.Type(t.NamedType())
+		if named == nil || named.Kind != schema.KindInputObject {
+			v.errorf(path, "object cannot be passed as %s of type %s", where, t)
+			return
+		}
+
+		v.arguments(path, "input type "+named.Name, "field", named.InputFields, val.Fields)
+	default:
+		if val.Literal == "null" {
+			if t.NonNull() {
+				v.errorf(path, "null cannot be passed as %s of type %s", where, t)
+			}
+			return
+		}
+
+		if t.IsList() {
+			v.value(path, where, t.Nullable().OfType, false, val)
+			return
+		}
+
+		named := v.schema.Type(t.NamedType())
+		if named == nil {
+			return
+		}
+
+		if !literalOf(named, val.Literal) {
+			v.errorf(path, "%s cannot be passed as %s of type %s", val.Literal, where, t)
+		}
+	}
+}
+
+// literalOf denotes whether or not literal is a valid literal of the named type t, custom
+// scalars accept any literal.
+func literalOf(t *schema.Type, literal string) bool {
+	isString := strings.HasPrefix(literal, `"`)
+	isInt := reNumber.FindString(literal) == literal && !strings.ContainsAny(literal, ".eE")
+	isFloat := reNumber.FindString(literal) == literal
+
+	switch t.Kind { //nolint:exhaustive // Why: only input types are passed as arguments.
+	case schema.KindEnum:
+		return t.EnumValue(literal) != nil
+	case schema.KindInputObject:
+		return false
+	}
+
+	switch t.Name {
+	case "Int":
+		return isInt
+	case "Float":
+		return isFloat
+	case "String":
+		return isString
+	case "ID":
+		return isString || isInt
+	case "Boolean":
+		return literal == "true" || literal == "false"
+	default:
+		return true
+	}
+}
+
+// compatible denotes whether or not a variable of type vt can be passed where a value of type t
+// is expected.
+func compatible(vt, t *schema.TypeRef) bool {
+	switch {
+	case t.NonNull():
+		return vt.NonNull() && compatible(vt.OfType, t.OfType)
+	case vt.NonNull():
+		return compatible(vt.OfType, t)
+	case t.Kind == schema.KindList:
+		return vt.Kind == schema.KindList && compatible(vt.OfType, t.OfType)
+	case vt.Kind == schema.KindList:
+		return false
+	default:
+		return vt.Name == t.Name
+	}
+}
+
+// nullable denotes whether or not the Go type rt can hold null.
+func nullable(rt reflect.Type) bool {
+	switch rt.Kind() { //nolint:exhaustive // Why: no other kind has a nil value that JSON null decodes into.
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	}
+	return false
+}
+
+// holds denotes whether or not data of type t decodes into the Go type rt.
+func (v *validator) holds(rt reflect.Type, t *schema.TypeRef) bool { //nolint:gocyclo
+	// Types that decode JSON themselves are assumed to know what they're doing.
+	if rt.Kind() == reflect.Interface || reflect.PointerTo(rt).Implements(unmarshalerType) {
+		return true
+	}
+
+	if !t.NonNull() && !nullable(rt) {
+		return false
+	}
+	t = t.Nullable()
+
+	if rt.Kind() == reflect.Ptr {
+		return v.holds(rt.Elem(), schema.NonNullOf(t))
+	}
+
+	if t.Kind == schema.KindList {
+		if rt.Kind() != reflect.Slice && rt.Kind() != reflect.Array {
+			return false
+		}
+		return v.holds(rt.Elem(), t.OfType)
+	}
+
+	named := v.schema.Type(t.Name)
+	if named == nil {
+		return true
+	}
+
+	switch named.Kind { //nolint:exhaustive // Why: named types are never lists or non-null.
+	case schema.KindObject, schema.KindInterface, schema.KindUnion:
+		return rt.Kind() == reflect.Struct
+	case schema.KindEnum:
+		return rt.Kind() == reflect.String
+	}
+
+	switch named.Name {
+	case "Int":
+		switch rt.Kind() { //nolint:exhaustive // Why: only integers hold integers.
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		}
+		return false
+	case "Float":
+		return rt.Kind() == reflect.Float32 || rt.Kind() == reflect.Float64
+	case "String", "ID":
+		return rt.Kind() == reflect.String
+	case "Boolean":
+		return rt.Kind() == reflect.Bool
+	default:
+		// Custom scalars can be serialized as anything.
+		return true
+	}
+}
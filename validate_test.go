@@ -0,0 +1,287 @@
+package goql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/getoutreach/goql/schema"
+)
+
+// validationSDL is the schema that operations are validated against in TestValidate.
+const validationSDL = `
+scalar DateTime
+
+type Query {
+  user(id: ID!): User
+  users(first: Int = 10, filter: UserFilter): [User!]!
+  node(id: ID!): Node
+  search(text: String!): [SearchResult!]!
+}
+
+type Mutation {
+  updateUser(id: ID!, input: UserInput!): User
+}
+
+interface Node {
+  id: ID!
+}
+
+type User implements Node {
+  id: ID!
+  name: String
+  age: Int!
+  role: Role!
+  createdAt: DateTime!
+  friends(first: Int!): [User!]
+}
+
+type Team implements Node {
+  id: ID!
+  members: [User!]!
+}
+
+union SearchResult = User | Team
+
+enum Role {
+  ADMIN
+  MEMBER
+}
+
+input UserFilter {
+  role: Role
+  names: [String!]
+}
+
+input UserInput {
+  name: String
+  role: Role!
+}
+`
+
+// validationUser is a named fragment on User used in TestValidate.
+type validationUser struct {
+	ID   string
+	Name *string
+}
+
+func (validationUser) FragmentName() string { return "ValidationUser" }
+func (validationUser) FragmentOn() string   { return "User" }
+
+func TestValidate(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	s, err := schema.ParseSDL(validationSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type ValidQuery struct {
+		User *struct {
+			ID        string
+			Name      *string `goql:"@include($withName)"`
+			Age       int
+			Role      string
+			CreatedAt time.Time
+			Friends   []struct {
+				ID string
+			} `goql:"friends(first:$first<Int!>)"`
+		} `goql:"user(id:$id<ID!>)"`
+		Users []struct {
+			validationUser
+		} `goql:"users(filter:{role:ADMIN,names:[$name<String!>]})"`
+		Node *struct {
+			Typename string `goql:"__typename"`
+			OnUser   struct {
+				Name *string
+			} `goql:"... on User"`
+		} `goql:"node(id:$nodeID<ID!>)"`
+		Search []struct {
+			Team struct {
+				Members []validationUser
+			} `goql:"... on Team"`
+		} `goql:"search(text:$text<String!>)"`
+	}
+
+	type InvalidQuery struct {
+		User *struct {
+			ID       int
+			Name     string
+			Email    *string
+			Nickname *string `goql:"-"`
+			Friends  []struct {
+				ID string
+			} `goql:"friends(count:10)"`
+		} `goql:"user(id:$id<Int!>)"`
+		Users []struct {
+			ID string
+		} `goql:"users(filter:{role:OWNER,team:$team<ID>})"`
+		Node *struct {
+			ID struct {
+				Value string
+			}
+			OnRole struct {
+				Name *string
+			} `goql:"... on Role"`
+		} `goql:"node(id:$id<Int!>)"`
+		Search []struct {
+			ID string
+		} `goql:"search()"`
+	}
+
+	type InvalidMutation struct {
+		UpdateUser *struct {
+			ID string
+		} `goql:"updateUser(id:$id<ID!>,input:$input<UserInput>)"`
+	}
+
+	tt := []struct {
+		Name          string
+		OperationType string
+		Input         interface{}
+		Expected      SchemaErrors
+	}{
+		{
+			Name:          "Valid",
+			OperationType: "query",
+			Input:         ValidQuery{},
+		},
+		{
+			Name:          "Invalid",
+			OperationType: "query",
+			Input:         &InvalidQuery{},
+			Expected: SchemaErrors{
+				{
+					Path:    "InvalidQuery.User",
+					Message: `variable $id of type Int! cannot be passed as argument "id" of field Query.user of type ID!`,
+				},
+				{
+					Path:    "InvalidQuery.User.ID",
+					Message: "Go type int cannot hold GraphQL type ID! of field User.id",
+				},
+				{
+					Path:    "InvalidQuery.User.Name",
+					Message: "Go type string cannot hold GraphQL type String of field User.name, which can be null, use a pointer",
+				},
+				{
+					Path:    "InvalidQuery.User.Email",
+					Message: `unknown field "email" on type User`,
+				},
+				{
+					Path:    "InvalidQuery.User.Friends",
+					Message: `unknown argument "count" of field User.friends`,
+				},
+				{
+					Path:    "InvalidQuery.User.Friends",
+					Message: `missing argument "first" of type Int! of field User.friends`,
+				},
+				{
+					Path:    "InvalidQuery.Users",
+					Message: `OWNER cannot be passed as field "role" of input type UserFilter of type Role`,
+				},
+				{
+					Path:    "InvalidQuery.Users",
+					Message: `unknown field "team" of input type UserFilter`,
+				},
+				{
+					Path:    "InvalidQuery.Node",
+					Message: `variable $id of type Int! cannot be passed as argument "id" of field Query.node of type ID!`,
+				},
+				{
+					Path:    "InvalidQuery.Node.ID",
+					Message: "Go type struct { Value string } cannot hold GraphQL type ID! of field Node.id",
+				},
+				{
+					Path:    "InvalidQuery.Node.OnRole",
+					Message: "fragment cannot be on Role, which isn't an object, interface, or union type",
+				},
+				{
+					Path:    "InvalidQuery.Search",
+					Message: `missing argument "text" of type String! of field Query.search`,
+				},
+				{
+					Path:    "InvalidQuery.Search.ID",
+					Message: `unknown field "id" on type SearchResult`,
+				},
+			},
+		},
+		{
+			Name:          "InvalidMutation",
+			OperationType: "mutation",
+			Input:         InvalidMutation{},
+			Expected: SchemaErrors{
+				{
+					Path:    "InvalidMutation.UpdateUser",
+					Message: `variable $input of type UserInput cannot be passed as argument "input" of field Mutation.updateUser of type UserInput!`,
+				},
+			},
+		},
+		{
+			Name:          "UnsupportedOperationType",
+			OperationType: "subscription",
+			Input:         ValidQuery{},
+			Expected: SchemaErrors{
+				{
+					Message: "schema doesn't support subscription operations",
+				},
+			},
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			var err error
+			switch test.OperationType {
+			case "query":
+				err = ValidateQuery(s, test.Input)
+			case "mutation":
+				err = ValidateMutation(s, test.Input)
+			case "subscription":
+				err = ValidateSubscription(s, test.Input)
+			}
+
+			if test.Expected == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			errs, ok := err.(SchemaErrors) //nolint:errorlint // Why: the type is returned as is.
+			if !ok {
+				t.Fatalf("expected SchemaErrors, got %T: %v", err, err)
+			}
+
+			if !reflect.DeepEqual(errs, test.Expected) {
+				t.Errorf("expected errors:\n%v\ngot:\n%v", test.Expected, errs)
+				for i := range errs {
+					t.Logf("%s", errs[i].Error())
+				}
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+// TestValidateNilOption tests that nil options are skipped, as they are when marshaling.
+func TestValidateNilOption(t *testing.T) {
+	t.Parallel()
+
+	s, err := schema.ParseSDL(validationSDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Query struct {
+		User *struct {
+			ID string
+		} `goql:"user(id:$id<ID!>)"`
+	}
+
+	if err := ValidateQuery(s, Query{}, nil, OptFallbackJSONTag); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
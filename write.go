@@ -0,0 +1,39 @@
+package goql
+
+import (
+	"io"
+)
+
+// WriteQueryDocument writes the GraphQL document of the query operation q to w, pretty-printed
+// rather than in the compact form returned by MarshalQuery, e.g. to review the operations a
+// client performs or to register them with a schema registry. All of the fields of q are
+// written, as if no sparse fieldset was passed.
+func WriteQueryDocument(w io.Writer, q interface{}, opts ...marshalOption) error {
+	return writeDocument(w, MarshalQueryWithOptions, q, opts...)
+}
+
+// WriteMutationDocument writes the GraphQL document of the mutation operation q to w, see
+// WriteQueryDocument.
+func WriteMutationDocument(w io.Writer, q interface{}, opts ...marshalOption) error {
+	return writeDocument(w, MarshalMutationWithOptions, q, opts...)
+}
+
+// WriteSubscriptionDocument writes the GraphQL document of the subscription operation q to w,
+// see WriteQueryDocument.
+func WriteSubscriptionDocument(w io.Writer, q interface{}, opts ...marshalOption) error {
+	return writeDocument(w, MarshalSubscriptionWithOptions, q, opts...)
+}
+
+// writeDocument marshals q through the given marshal func, pretty-printed as with
+// OptPrettyPrint, and writes the resulting document to w.
+func writeDocument(w io.Writer, marshal func(interface{}, Fields, ...marshalOption) (string, error),
+	q interface{}, opts ...marshalOption) error {
+	// The options of the caller are copied rather than appended to, since they may be shared.
+	doc, err := marshal(q, nil, append(opts[:len(opts):len(opts)], OptPrettyPrint)...)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, doc+"\n")
+	return err
+}
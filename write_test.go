@@ -0,0 +1,97 @@
+package goql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeGetUser is a named operation written in TestWriteDocument.
+type writeGetUser struct {
+	User struct {
+		TestUserSummary
+		Friends []struct {
+			ID string `goql:"id"`
+		} `goql:"users(first:$first<Int>=10,filter:{names:[\"a\",\"b\"]}),@alias(friends),@include($withFriends)"`
+		Node struct {
+			OnUser struct {
+				Email string
+			} `goql:"... on User"`
+		} `goql:"node(id:$id<ID!>)"`
+	} `goql:"user(id:$id<ID!>)"`
+}
+
+func (writeGetUser) OperationName() string { return "GetUser" }
+
+func TestWriteDocument(t *testing.T) {
+	t.Parallel()
+
+	type UpdateUser struct {
+		UpdateUser struct {
+			ID string `json:"id"`
+		} `goql:"updateUser(id:$id<ID!>)"`
+	}
+
+	tt := []struct {
+		Name     string
+		Write    func(*strings.Builder) error
+		Expected string
+	}{
+		{
+			Name: "Query",
+			Write: func(b *strings.Builder) error {
+				return WriteQueryDocument(b, writeGetUser{})
+			},
+			Expected: `query GetUser($id: ID!, $first: Int = 10, $withFriends: Boolean!) {
+  user(id: $id) {
+    ...UserSummary
+    friends: users(first: $first, filter: {names: ["a", "b"]}) @include(if: $withFriends) {
+      id
+    }
+    node(id: $id) {
+      ... on User {
+        email
+      }
+      __typename
+    }
+  }
+}
+
+fragment UserSummary on User {
+  id
+  name
+}
+`,
+		},
+		{
+			Name: "Mutation",
+			Write: func(b *strings.Builder) error {
+				return WriteMutationDocument(b, UpdateUser{}, OptFallbackJSONTag)
+			},
+			Expected: `mutation ($id: ID!) {
+  updateUser(id: $id) {
+    id
+  }
+}
+`,
+		},
+	}
+
+	for _, test := range tt {
+		fn := func(t *testing.T) {
+			t.Parallel()
+
+			var b strings.Builder
+			if err := test.Write(&b); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.Expected, b.String()); diff != "" {
+				t.Errorf("unexpected document (-want +got):\n%s", diff)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}